@@ -13,12 +13,14 @@ import (
 )
 
 var (
-	namespace     string
-	includeLimits bool
-	outputFormat  string
-	alertSlack    string
-	logLevel      string
-	logFormat     string
+	namespace               string
+	includeLimits           bool
+	includeUnavailableNodes bool
+	outputFormat            string
+	alertSlack              string
+	logLevel                string
+	logFormat               string
+	schedulerConfig         string
 )
 
 var rootCmd = &cobra.Command{
@@ -45,10 +47,12 @@ Examples:
 func init() {
 	rootCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Target namespace to analyze (empty for cluster-wide)")
 	rootCmd.Flags().BoolVar(&includeLimits, "include-limits", false, "Use resource limits instead of requests for analysis")
+	rootCmd.Flags().BoolVar(&includeUnavailableNodes, "include-unavailable-nodes", false, "Evaluate cordoned and NotReady nodes as scheduling candidates")
 	rootCmd.Flags().StringVarP(&outputFormat, "output", "o", "human", "Output format: human, json, yaml")
 	rootCmd.Flags().StringVar(&alertSlack, "alert-slack", "", "Slack webhook URL for notifications (optional)")
 	rootCmd.Flags().StringVar(&logLevel, "log-level", "info", "Log level: debug, info, warn, error")
 	rootCmd.Flags().StringVar(&logFormat, "log-format", "text", "Log format: text, json")
+	rootCmd.Flags().StringVar(&schedulerConfig, "scheduler-config", "", "KubeSchedulerConfiguration file whose profiles the analysis applies (optional)")
 }
 
 func validateFlags() error {
@@ -127,6 +131,17 @@ func runAnalysis() error {
 	logrus.Debug("Successfully created Kubernetes client")
 
 	analyzer := internal.NewAnalyzer(fetcher)
+	analyzer.SetIncludeUnavailableNodes(includeUnavailableNodes)
+
+	if schedulerConfig != "" {
+		config, err := internal.LoadSchedulerConfig(schedulerConfig)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to load scheduler config")
+			return fmt.Errorf("failed to load scheduler config: %w", err)
+		}
+		analyzer.SetSchedulerConfig(config)
+		logrus.WithField("scheduler_config", schedulerConfig).Debug("Applying scheduler profiles")
+	}
 
 	results, err := analyzer.AnalyzePodSchedulability(ctx, namespace, includeLimits)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
@@ -13,13 +14,24 @@ import (
 type FetcherInterface interface {
 	FetchNodes(ctx context.Context) ([]types.NodeInfo, error)
 	FetchPendingPods(ctx context.Context, namespace string) ([]types.PodInfo, error)
+	FetchScheduledPods(ctx context.Context) ([]types.PodInfo, error)
+	FetchNamespaceLabels(ctx context.Context) (map[string]map[string]string, error)
+	FetchPodDisruptionBudgets(ctx context.Context) ([]types.PodDisruptionBudgetInfo, error)
+	FetchSchedulerLeases(ctx context.Context) ([]string, error)
 }
 
 // Analyzer provides functionality to analyze pod schedulability and resource constraints
 // in a Kubernetes cluster. It uses a FetcherInterface to retrieve cluster information and
 // performs analysis to determine why pods might be pending.
 type Analyzer struct {
-	fetcher FetcherInterface
+	fetcher                  FetcherInterface
+	includeUnavailableNodes  bool
+	namespaceLabels          map[string]map[string]string
+	disruptionBudgets        []types.PodDisruptionBudgetInfo
+	disruptionBudgetsUnknown bool
+	knownSchedulers          map[string]bool
+	noRequiredAntiAffinity   bool
+	schedulerConfig          *SchedulerConfig
 }
 
 // NewAnalyzer creates a new Analyzer instance with the provided FetcherInterface.
@@ -36,9 +48,19 @@ func NewAnalyzer(fetcher FetcherInterface) *Analyzer {
 	}
 }
 
+// SetIncludeUnavailableNodes controls whether cordoned and NotReady nodes are kept as
+// scheduling candidates. By default they are excluded, matching the scheduler.
+//
+// Parameters:
+//   - include: If true, cordoned and NotReady nodes are evaluated like any other node
+func (a *Analyzer) SetIncludeUnavailableNodes(include bool) {
+	a.includeUnavailableNodes = include
+}
+
 // AnalyzePodSchedulability analyzes all pending pods in the specified namespace (or cluster-wide)
 // to determine their schedulability based on resource availability. It compares pod resource
-// requirements against node allocatable resources to identify scheduling constraints.
+// requirements against the free capacity of each node, i.e. allocatable resources minus the
+// requests of pods already bound to that node, to identify scheduling constraints.
 //
 // Parameters:
 //   - ctx: Context for the operation, used for cancellation and timeout
@@ -66,6 +88,46 @@ func (a *Analyzer) AnalyzePodSchedulability(ctx context.Context, namespace strin
 		return nil, fmt.Errorf("failed to fetch nodes: %w", err)
 	}
 
+	scheduledPods, err := a.fetcher.FetchScheduledPods(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to fetch scheduled pods for analysis")
+		return nil, fmt.Errorf("failed to fetch scheduled pods: %w", err)
+	}
+
+	if usesNamespaceSelector(pods) || usesNamespaceSelector(scheduledPods) {
+		a.namespaceLabels, err = a.fetcher.FetchNamespaceLabels(ctx)
+		if err != nil {
+			logrus.WithError(err).Warn(
+				"Failed to fetch namespace labels; inter-pod affinity terms with a namespaceSelector are not evaluated")
+			pods = withoutNamespaceSelectorTerms(pods)
+			scheduledPods = withoutNamespaceSelectorTerms(scheduledPods)
+		}
+	}
+
+	if mayPreempt(unscheduledPods(pods), scheduledPods) {
+		a.disruptionBudgets, err = a.fetcher.FetchPodDisruptionBudgets(ctx)
+		if err != nil {
+			logrus.WithError(err).Warn(
+				"Failed to fetch PodDisruptionBudgets; preemption is simulated without them and marked unverified")
+			a.disruptionBudgets = nil
+			a.disruptionBudgetsUnknown = true
+		}
+	}
+
+	a.knownSchedulers = servedSchedulers(scheduledPods)
+	if a.namesUnservedScheduler(unscheduledPods(pods)) {
+		leases, err := a.fetcher.FetchSchedulerLeases(ctx)
+		if err != nil {
+			logrus.WithError(err).Warn(
+				"Failed to fetch scheduler Leases; schedulers are only known from running pods, events and profiles")
+		}
+		for _, name := range leases {
+			a.knownSchedulers[name] = true
+		}
+	}
+	a.noRequiredAntiAffinity = !hasRequiredAntiAffinity(pods) && !hasRequiredAntiAffinity(scheduledPods)
+	nodes = a.applyScheduledPodRequests(nodes, scheduledPods)
+
 	logrus.WithFields(logrus.Fields{
 		"pods_count":  len(pods),
 		"nodes_count": len(nodes),
@@ -73,8 +135,34 @@ func (a *Analyzer) AnalyzePodSchedulability(ctx context.Context, namespace strin
 
 	results := make([]types.AnalysisResult, 0, len(pods))
 	unschedulableCount := 0
+	boundCount := 0
+	nominatedCount := 0
 
 	for _, pod := range pods {
+		if pod.NodeName != "" {
+			results = append(results, analyzeBoundPod(pod))
+			boundCount++
+			logrus.WithFields(logrus.Fields{
+				"pod_name":      pod.Name,
+				"pod_namespace": pod.Namespace,
+				"node_name":     pod.NodeName,
+			}).Debug("Pod is bound to a node but not running; skipping capacity checks")
+			continue
+		}
+
+		if pod.NominatedNodeName != "" {
+			if result, ok := analyzeNominatedPod(pod, nodes); ok {
+				results = append(results, result)
+				nominatedCount++
+				logrus.WithFields(logrus.Fields{
+					"pod_name":      pod.Name,
+					"pod_namespace": pod.Namespace,
+					"node_name":     pod.NominatedNodeName,
+				}).Debug("Pod is nominated to a node after preemption; skipping capacity checks")
+				continue
+			}
+		}
+
 		result := a.analyzeSinglePod(pod, nodes, includeLimits)
 		results = append(results, result)
 
@@ -93,29 +181,196 @@ func (a *Analyzer) AnalyzePodSchedulability(ctx context.Context, namespace strin
 		}
 	}
 
+	unschedulableCount += a.simulateCollectiveScheduling(results, nodes, includeLimits)
+
 	logrus.WithFields(logrus.Fields{
 		"total_pods":         len(results),
 		"unschedulable_pods": unschedulableCount,
-		"schedulable_pods":   len(results) - unschedulableCount,
+		"schedulable_pods":   len(results) - unschedulableCount - boundCount - nominatedCount,
+		"bound_pods":         boundCount,
+		"preempting_pods":    nominatedCount,
 	}).Info("Pod schedulability analysis completed")
 
 	return results, nil
 }
 
 // analyzeSinglePod performs schedulability analysis for a single pod against available nodes.
-// It determines if the pod can be scheduled based on resource requirements and provides
-// detailed reasons and suggestions when scheduling is not possible.
+// Pods with scheduling gates or an unknown schedulerName are reported as such without a fit
+// analysis, as no scheduler will consider them.
+// Nodes the pod cannot be placed on because they are cordoned or NotReady, or because of hard
+// scheduling constraints (nodeSelector, required node affinity, untolerated taints, volume
+// topology, CSI volume attach limits, host port conflicts, required inter-pod affinity and
+// anti-affinity, DoNotSchedule topology spread constraints and exhausted pod slots), are
+// removed first, so a pod restricted to a node pool is only evaluated against that pool's
+// capacity. The pod is then considered schedulable only when a single remaining node has
+// enough free CPU, memory and extended resources (GPUs, hugepages, ephemeral-storage) to
+// satisfy its requirements simultaneously. It provides detailed reasons and suggestions when
+// scheduling is not possible, and reports whether preempting lower-priority pods would make
+// room. When a scheduler configuration is set, the filters whose plugins the pod's scheduler
+// profile disables are skipped and the extended resources its NodeResourcesFit ignores are
+// not checked.
 //
 // Parameters:
 //   - pod: The pod information to analyze
-//   - nodes: Available nodes in the cluster with their resource information
+//   - nodes: Available nodes in the cluster with their allocatable and requested resources
 //   - includeLimits: If true, uses resource limits instead of requests for comparison
 //
 // Returns:
 //   - types.AnalysisResult: Detailed analysis result including schedulability status, reasons, and suggestions
 func (a *Analyzer) analyzeSinglePod(pod types.PodInfo, nodes []types.NodeInfo, includeLimits bool) types.AnalysisResult {
-	maxAvailableCPU, maxAvailableMemory := a.findMaxAvailableResources(nodes)
+	if result, blocked := a.schedulingBlocker(pod); blocked {
+		return result
+	}
+
+	podCPU, podMemory, resourceType, extended := a.requirements(pod, includeLimits)
+
+	candidates, placement := a.placementCandidates(pod, nodes)
+	preemptible := candidates
+	candidates, volumeLimitExclusion := a.runFilter(pod, nodeVolumeLimitsPlugin, candidates, filterByVolumeLimits)
+	candidates, hostPortExclusion := a.runFilter(pod, nodePortsPlugin, candidates, filterByHostPorts)
+	candidates, podAffinityExclusion := a.runFilter(pod, interPodAffinityPlugin, candidates,
+		a.podAffinityFilter(nodes))
+	candidates, spreadExclusion := a.runFilter(pod, podTopologySpreadPlugin, candidates,
+		func(pod types.PodInfo, kept []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+			return filterByTopologySpread(pod, kept, nodes)
+		})
+	candidates, podLimitExclusion := a.runFilter(pod, nodeResourcesFitPlugin, candidates,
+		func(_ types.PodInfo, kept []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+			return filterByPodCapacity(kept)
+		})
+	exclusions := placement.list(volumeLimitExclusion, hostPortExclusion, podAffinityExclusion, spreadExclusion,
+		podLimitExclusion)
+
+	maxAvailableCPU, maxAvailableMemory := a.findMaxAvailableResources(candidates)
+	maxFreeCPU, maxFreeMemory := a.findMaxFreeResources(candidates)
 
+	nodeFits := a.evaluateNodeFits(candidates, podCPU, podMemory, extended)
+	fittingNodes := fittingNodeNames(nodeFits)
+	isSchedulable := len(fittingNodes) > 0
+
+	var reasons []types.ReasonDetail
+	var suggestion string
+	if !isSchedulable {
+		if len(nodes) > 0 && len(candidates) == 0 {
+			reasons, suggestion = constraintReason(exclusions)
+		} else {
+			reasons, suggestion = a.buildFitReason(resourceType, podCPU, podMemory, extended, candidates, nodeFits,
+				maxAvailableCPU, maxAvailableMemory, maxFreeCPU, maxFreeMemory)
+			reasons = append(reasons, excludedFitReasons(exclusions, podCPU, podMemory, extended)...)
+		}
+	}
+
+	var preemption *types.PreemptionCandidate
+	if !isSchedulable {
+		preemption = a.simulatePreemption(pod, preemptible, nodes, podCPU, podMemory, extended)
+		if preemption != nil {
+			reasons = append(reasons, messageDetail(types.ReasonCodePreemptionPossible,
+				fmt.Sprintf("preempting %d lower-priority pod(s) on node %s would make room",
+					len(preemption.Victims), preemption.NodeName),
+				[]string{preemption.NodeName}, preemption.Victims))
+		}
+	}
+
+	result := types.AnalysisResult{
+		Pod:                pod,
+		Category:           types.PendingCategoryUnscheduled,
+		IsSchedulable:      isSchedulable,
+		Reason:             renderReasons(reasons),
+		Reasons:            reasons,
+		Suggestion:         suggestion,
+		MaxAvailableCPU:    maxAvailableCPU,
+		MaxAvailableMemory: maxAvailableMemory,
+		MaxFreeCPU:         maxFreeCPU,
+		MaxFreeMemory:      maxFreeMemory,
+		FittingNodes:       fittingNodes,
+		Nodes:              nodeFits,
+		Preemption:         preemption,
+		SchedulerReports:   pod.SchedulerReports,
+	}
+	result.EmptyDirMemoryWarning = emptyDirMemoryWarning(pod, nodeFits)
+
+	if !isSchedulable {
+		result.UnmatchedNodeSelector = placement.selector.details
+		result.UntoleratedTaints = placement.taint.details
+		result.FullNodes = podLimitExclusion.details
+		result.UnavailableNodes = placement.availability.details
+		result.PodAffinityConflicts = podAffinityExclusion.details
+		result.SkewedDomains = spreadExclusion.details
+		result.VolumeConflicts = placement.volume.details
+		result.VolumeLimitNodes = volumeLimitExclusion.details
+		result.HostPortConflicts = hostPortExclusion.details
+		result.NodeRejections, result.RejectionSummary = nodeRejections(exclusions, nodeFits, len(nodes))
+	}
+	result.SchedulerDisagreement = schedulerDisagreement(result, len(nodes))
+
+	return result
+}
+
+// emptyDirMemoryWarning warns when the pod's memory-backed emptyDir volumes may hold more
+// than the pod's memory limit, which their contents are charged to, or more than the free
+// memory of every node the pod fits on (or of every candidate node when it fits on none).
+// The scheduler ignores the volumes, so this never makes the pod unschedulable.
+//
+// Parameters:
+//   - pod: The pod whose emptyDir size limits are checked
+//   - nodeFits: The per-node fit of the pod on its candidate nodes
+//
+// Returns:
+//   - string: The warning, or an empty string if the volumes fit
+func emptyDirMemoryWarning(pod types.PodInfo, nodeFits []types.NodeFit) string {
+	if pod.MemoryBackedEmptyDir == nil {
+		return ""
+	}
+	size := *pod.MemoryBackedEmptyDir
+
+	if !pod.LimitsMemory.IsZero() && size.Cmp(pod.LimitsMemory) > 0 {
+		return fmt.Sprintf("memory-backed emptyDir volumes may hold %s, more than the pod's memory limit of %s; "+
+			"filling them gets its containers OOM-killed", size.String(), pod.LimitsMemory.String())
+	}
+
+	nodes := nodeFits
+	if fitting := fittingNodeFits(nodeFits); len(fitting) > 0 {
+		nodes = fitting
+	}
+	if len(nodes) == 0 {
+		return ""
+	}
+	var maxFree resource.Quantity
+	for _, nodeFit := range nodes {
+		if nodeFit.FreeMemory.Cmp(maxFree) > 0 {
+			maxFree = nodeFit.FreeMemory
+		}
+	}
+	if size.Cmp(maxFree) > 0 {
+		return fmt.Sprintf("memory-backed emptyDir volumes may hold %s, more than the free memory of any node "+
+			"the pod can be placed on (max: %s); filling them can exhaust the node's memory", size.String(), maxFree.String())
+	}
+	return ""
+}
+
+// fittingNodeFits returns the node fits marked as fitting, preserving order.
+func fittingNodeFits(nodeFits []types.NodeFit) []types.NodeFit {
+	var fitting []types.NodeFit
+	for _, nodeFit := range nodeFits {
+		if nodeFit.Fits {
+			fitting = append(fitting, nodeFit)
+		}
+	}
+	return fitting
+}
+
+// podRequirements returns the CPU and memory amounts the pod is evaluated with. When
+// includeLimits is set and the pod has limits, they replace the requests.
+//
+// Parameters:
+//   - pod: The pod whose requirements are returned
+//   - includeLimits: If true, prefers limits over requests
+//
+// Returns:
+//   - resource.Quantity: The CPU requirement
+//   - resource.Quantity: The memory requirement
+//   - string: Either "requests" or "limits", used in the wording of reasons
+func podRequirements(pod types.PodInfo, includeLimits bool) (resource.Quantity, resource.Quantity, string) {
 	podCPU := pod.RequestsCPU
 	podMemory := pod.RequestsMemory
 	resourceType := "requests"
@@ -130,41 +385,212 @@ func (a *Analyzer) analyzeSinglePod(pod types.PodInfo, nodes []types.NodeInfo, i
 		resourceType = "limits"
 	}
 
+	return podCPU, podMemory, resourceType
+}
+
+// buildResourceReason explains why a pod's CPU and memory requirements cannot be met by
+// any candidate node. Requirements larger than any node's allocatable resources are
+// reported first, followed by requirements that only exceed the capacity left free by
+// running pods, and finally the case where no single node satisfies both dimensions.
+//
+// Parameters:
+//   - resourceType: Either "requests" or "limits", used in the wording
+//   - podCPU, podMemory: The pod's CPU and memory requirements
+//   - maxAvailableCPU, maxAvailableMemory: Largest allocatable values across candidate nodes
+//   - maxFreeCPU, maxFreeMemory: Largest free values across candidate nodes
+//
+// Returns:
+//   - []types.ReasonDetail: The reasons the pod cannot be scheduled
+//   - string: A suggestion for resolving the issue
+func (a *Analyzer) buildResourceReason(resourceType string, podCPU, podMemory,
+	maxAvailableCPU, maxAvailableMemory, maxFreeCPU, maxFreeMemory resource.Quantity) ([]types.ReasonDetail, string) {
 	cpuFits := podCPU.Cmp(maxAvailableCPU) <= 0
 	memoryFits := podMemory.Cmp(maxAvailableMemory) <= 0
+	cpuFitsFree := podCPU.Cmp(maxFreeCPU) <= 0
+	memoryFitsFree := podMemory.Cmp(maxFreeMemory) <= 0
 
-	isSchedulable := cpuFits && memoryFits
+	cpuDetail := func(scope types.ReasonScope, available resource.Quantity) types.ReasonDetail {
+		return insufficientResourceDetail(resourceType, scope, corev1.ResourceCPU, podCPU, available)
+	}
+	memoryDetail := func(scope types.ReasonScope, available resource.Quantity) types.ReasonDetail {
+		return insufficientResourceDetail(resourceType, scope, corev1.ResourceMemory, podMemory, available)
+	}
 
-	var reason, suggestion string
-	if !isSchedulable {
-		switch {
-		case !cpuFits && !memoryFits:
-			reason = fmt.Sprintf("%s.cpu = %s and %s.memory = %s exceed all node allocatable resources (max CPU: %s, max memory: %s)",
-				resourceType, podCPU.String(), resourceType, podMemory.String(),
-				maxAvailableCPU.String(), maxAvailableMemory.String())
-			suggestion = fmt.Sprintf("Lower %s.cpu to <= %s and %s.memory to <= %s, or add nodes with higher capacity",
-				resourceType, maxAvailableCPU.String(), resourceType, maxAvailableMemory.String())
-		case !cpuFits:
-			reason = fmt.Sprintf("%s.cpu = %s exceeds all node allocatable.cpu (max: %s)",
-				resourceType, podCPU.String(), maxAvailableCPU.String())
-			suggestion = fmt.Sprintf("Lower %s.cpu to <= %s or add higher-CPU node",
-				resourceType, maxAvailableCPU.String())
-		default:
-			reason = fmt.Sprintf("%s.memory = %s exceeds all node allocatable.memory (max: %s)",
-				resourceType, podMemory.String(), maxAvailableMemory.String())
-			suggestion = fmt.Sprintf("Lower %s.memory to <= %s or add higher-memory node",
-				resourceType, maxAvailableMemory.String())
-		}
-	}
-
-	return types.AnalysisResult{
-		Pod:                pod,
-		IsSchedulable:      isSchedulable,
-		Reason:             reason,
-		Suggestion:         suggestion,
-		MaxAvailableCPU:    maxAvailableCPU,
-		MaxAvailableMemory: maxAvailableMemory,
+	var details []types.ReasonDetail
+	var suggestion string
+	switch {
+	case !cpuFits && !memoryFits:
+		details = []types.ReasonDetail{
+			cpuDetail(types.ReasonScopeAllocatable, maxAvailableCPU),
+			memoryDetail(types.ReasonScopeAllocatable, maxAvailableMemory),
+		}
+		suggestion = fmt.Sprintf("Lower %s.cpu to <= %s and %s.memory to <= %s, or add nodes with higher capacity",
+			resourceType, maxAvailableCPU.String(), resourceType, maxAvailableMemory.String())
+	case !cpuFits:
+		details = []types.ReasonDetail{cpuDetail(types.ReasonScopeAllocatable, maxAvailableCPU)}
+		suggestion = fmt.Sprintf("Lower %s.cpu to <= %s or add higher-CPU node",
+			resourceType, maxAvailableCPU.String())
+	case !memoryFits:
+		details = []types.ReasonDetail{memoryDetail(types.ReasonScopeAllocatable, maxAvailableMemory)}
+		suggestion = fmt.Sprintf("Lower %s.memory to <= %s or add higher-memory node",
+			resourceType, maxAvailableMemory.String())
+	case !cpuFitsFree && !memoryFitsFree:
+		details = []types.ReasonDetail{
+			cpuDetail(types.ReasonScopeFree, maxFreeCPU),
+			memoryDetail(types.ReasonScopeFree, maxFreeMemory),
+		}
+		suggestion = fmt.Sprintf("Lower %s.cpu to <= %s and %s.memory to <= %s, scale down other workloads, or add nodes",
+			resourceType, maxFreeCPU.String(), resourceType, maxFreeMemory.String())
+	case !cpuFitsFree:
+		details = []types.ReasonDetail{cpuDetail(types.ReasonScopeFree, maxFreeCPU)}
+		suggestion = fmt.Sprintf("Lower %s.cpu to <= %s, scale down other workloads, or add nodes",
+			resourceType, maxFreeCPU.String())
+	case !memoryFitsFree:
+		details = []types.ReasonDetail{memoryDetail(types.ReasonScopeFree, maxFreeMemory)}
+		suggestion = fmt.Sprintf("Lower %s.memory to <= %s, scale down other workloads, or add nodes",
+			resourceType, maxFreeMemory.String())
+	default:
+		details = []types.ReasonDetail{notColocatedDetail(resourceType, []types.ResourceAmount{
+			{Name: corev1.ResourceCPU, Requested: podCPU.DeepCopy(), Available: maxFreeCPU.DeepCopy()},
+			{Name: corev1.ResourceMemory, Requested: podMemory.DeepCopy(), Available: maxFreeMemory.DeepCopy()},
+		})}
+		suggestion = fmt.Sprintf("Lower %s.cpu or %s.memory so that both fit within one node, or add a node with at least %s CPU and %s memory free",
+			resourceType, resourceType, podCPU.String(), podMemory.String())
+	}
+
+	return details, suggestion
+}
+
+// evaluateNodeFits checks the pod against the free capacity of every node. A node
+// only qualifies when it can hold the CPU, memory and every extended resource
+// requirement at the same time, so a pod is schedulable only if at least one
+// concrete node fits it.
+//
+// Parameters:
+//   - nodes: Slice of node information containing allocatable and requested resources
+//   - podCPU: CPU amount the pod needs on a single node
+//   - podMemory: Memory amount the pod needs on a single node
+//   - extended: Amounts of other resources the pod needs on a single node
+//
+// Returns:
+//   - []types.NodeFit: Per-node capacity and fit outcome, in input order
+func (a *Analyzer) evaluateNodeFits(nodes []types.NodeInfo, podCPU, podMemory resource.Quantity,
+	extended corev1.ResourceList) []types.NodeFit {
+	nodeFits := make([]types.NodeFit, 0, len(nodes))
+	fittingCount := 0
+
+	for _, node := range nodes {
+		freeCPU, freeMemory := freeResources(node)
+		insufficient := insufficientResources(node, podCPU, podMemory, extended)
+		fits := len(insufficient) == 0
+		if fits {
+			fittingCount++
+		}
+
+		var extendedFree corev1.ResourceList
+		if len(extended) > 0 {
+			extendedFree = make(corev1.ResourceList, len(extended))
+			for name := range extended {
+				extendedFree[name] = freeResource(node, name)
+			}
+		}
+
+		nodeFits = append(nodeFits, types.NodeFit{
+			Name:                  node.Name,
+			AllocatableCPU:        node.AllocatableCPU.DeepCopy(),
+			AllocatableMemory:     node.AllocatableMemory.DeepCopy(),
+			FreeCPU:               freeCPU,
+			FreeMemory:            freeMemory,
+			ExtendedFree:          extendedFree,
+			InsufficientResources: insufficient,
+			Fits:                  fits,
+		})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"pod_cpu":            podCPU.String(),
+		"pod_memory":         podMemory.String(),
+		"fitting_node_count": fittingCount,
+	}).Debug("Evaluated per-node resource fit")
+
+	return nodeFits
+}
+
+// fittingNodeNames returns the names of the nodes marked as fitting, preserving order.
+func fittingNodeNames(nodeFits []types.NodeFit) []string {
+	var names []string
+	for _, nodeFit := range nodeFits {
+		if nodeFit.Fits {
+			names = append(names, nodeFit.Name)
+		}
+	}
+	return names
+}
+
+// applyScheduledPodRequests sums the requests of pods already bound to each node and
+// records them on the matching NodeInfo. Pods bound to nodes that are not in the list
+// are ignored.
+//
+// Parameters:
+//   - nodes: Slice of node information to annotate
+//   - scheduledPods: Pods with NodeName set, as returned by FetchScheduledPods
+//
+// Returns:
+//   - []types.NodeInfo: The nodes with RequestedCPU, RequestedMemory, Requested, PodCount and Pods populated
+func (a *Analyzer) applyScheduledPodRequests(nodes []types.NodeInfo, scheduledPods []types.PodInfo) []types.NodeInfo {
+	nodeIndex := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		nodeIndex[node.Name] = i
 	}
+
+	for _, pod := range scheduledPods {
+		i, ok := nodeIndex[pod.NodeName]
+		if !ok {
+			continue
+		}
+		nodes[i].RequestedCPU.Add(pod.RequestsCPU)
+		nodes[i].RequestedMemory.Add(pod.RequestsMemory)
+		nodes[i].PodCount++
+		nodes[i].Pods = append(nodes[i].Pods, pod)
+
+		for name, quantity := range pod.Requests {
+			if nodes[i].Requested == nil {
+				nodes[i].Requested = make(corev1.ResourceList)
+			}
+			requested := nodes[i].Requested[name]
+			requested.Add(quantity)
+			nodes[i].Requested[name] = requested
+		}
+	}
+
+	for _, node := range nodes {
+		logrus.WithFields(logrus.Fields{
+			"node_name":        node.Name,
+			"requested_cpu":    node.RequestedCPU.String(),
+			"requested_memory": node.RequestedMemory.String(),
+			"pod_count":        node.PodCount,
+		}).Debug("Calculated resources requested by scheduled pods")
+	}
+
+	return nodes
+}
+
+// freeResources returns the CPU and memory left on a node after subtracting the requests
+// of pods already bound to it. Values never go below zero.
+func freeResources(node types.NodeInfo) (resource.Quantity, resource.Quantity) {
+	freeCPU := node.AllocatableCPU.DeepCopy()
+	freeCPU.Sub(node.RequestedCPU)
+	if freeCPU.Sign() < 0 {
+		freeCPU = resource.Quantity{}
+	}
+
+	freeMemory := node.AllocatableMemory.DeepCopy()
+	freeMemory.Sub(node.RequestedMemory)
+	if freeMemory.Sign() < 0 {
+		freeMemory = resource.Quantity{}
+	}
+
+	return freeCPU, freeMemory
 }
 
 // findMaxAvailableResources finds the maximum CPU and memory resources available
@@ -197,6 +623,36 @@ func (a *Analyzer) findMaxAvailableResources(nodes []types.NodeInfo) (resource.Q
 	return maxCPU, maxMemory
 }
 
+// findMaxFreeResources finds the maximum free CPU and memory across all nodes, where
+// free means allocatable minus the requests of pods already bound to the node.
+//
+// Parameters:
+//   - nodes: Slice of node information containing allocatable and requested resources
+//
+// Returns:
+//   - resource.Quantity: Maximum free CPU across all nodes
+//   - resource.Quantity: Maximum free memory across all nodes
+func (a *Analyzer) findMaxFreeResources(nodes []types.NodeInfo) (resource.Quantity, resource.Quantity) {
+	var maxCPU, maxMemory resource.Quantity
+
+	for _, node := range nodes {
+		freeCPU, freeMemory := freeResources(node)
+		if freeCPU.Cmp(maxCPU) > 0 {
+			maxCPU = freeCPU
+		}
+		if freeMemory.Cmp(maxMemory) > 0 {
+			maxMemory = freeMemory
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"max_free_cpu":    maxCPU.String(),
+		"max_free_memory": maxMemory.String(),
+	}).Debug("Calculated maximum free resources across all nodes")
+
+	return maxCPU, maxMemory
+}
+
 // EvaluateResourceConstraints evaluates cluster-wide resource constraints and bottlenecks.
 // This method is currently a placeholder for future implementation of advanced constraint analysis.
 //
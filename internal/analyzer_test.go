@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
@@ -26,6 +27,26 @@ func (m *MockFetcher) FetchPendingPods(ctx context.Context, namespace string) ([
 	return args.Get(0).([]types.PodInfo), args.Error(1)
 }
 
+func (m *MockFetcher) FetchScheduledPods(ctx context.Context) ([]types.PodInfo, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]types.PodInfo), args.Error(1)
+}
+
+func (m *MockFetcher) FetchNamespaceLabels(ctx context.Context) (map[string]map[string]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[string]map[string]string), args.Error(1)
+}
+
+func (m *MockFetcher) FetchPodDisruptionBudgets(ctx context.Context) ([]types.PodDisruptionBudgetInfo, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]types.PodDisruptionBudgetInfo), args.Error(1)
+}
+
+func (m *MockFetcher) FetchSchedulerLeases(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func TestNewAnalyzer(t *testing.T) {
 	mockFetcher := &MockFetcher{}
 	analyzer := NewAnalyzer(mockFetcher)
@@ -56,6 +77,7 @@ func TestAnalyzePodSchedulability_Success(t *testing.T) {
 
 	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return(pods, nil)
 	mockFetcher.On("FetchNodes", mock.Anything).Return(nodes, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return([]types.PodInfo{}, nil)
 
 	analyzer := NewAnalyzer(mockFetcher)
 	ctx := context.Background()
@@ -74,6 +96,82 @@ func TestAnalyzePodSchedulability_Success(t *testing.T) {
 	mockFetcher.AssertExpectations(t)
 }
 
+func TestAnalyzePodSchedulability_ScheduledPodsConsumeCapacity(t *testing.T) {
+	mockFetcher := &MockFetcher{}
+
+	pods := []types.PodInfo{
+		{
+			Name:           "test-pod",
+			Namespace:      "default",
+			RequestsCPU:    resource.MustParse("1"),
+			RequestsMemory: resource.MustParse("1Gi"),
+		},
+	}
+
+	nodes := []types.NodeInfo{
+		{
+			Name:              "node1",
+			AllocatableCPU:    resource.MustParse("2"),
+			AllocatableMemory: resource.MustParse("4Gi"),
+		},
+	}
+
+	scheduledPods := []types.PodInfo{
+		{
+			Name:           "running-pod",
+			Namespace:      "default",
+			NodeName:       "node1",
+			RequestsCPU:    resource.MustParse("1500m"),
+			RequestsMemory: resource.MustParse("1Gi"),
+		},
+	}
+
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return(pods, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return(nodes, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return(scheduledPods, nil)
+
+	analyzer := NewAnalyzer(mockFetcher)
+	ctx := context.Background()
+
+	results, err := analyzer.AnalyzePodSchedulability(ctx, "default", false)
+
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	result := results[0]
+	assert.False(t, result.IsSchedulable)
+	assert.Equal(t, "requests.cpu = 1 exceeds the free cpu left by running pods on all nodes (max free: 500m)", result.Reason)
+	assert.Equal(t, "Lower requests.cpu to <= 500m, scale down other workloads, or add nodes", result.Suggestion)
+	assert.True(t, resource.MustParse("2").Equal(result.MaxAvailableCPU))
+	assert.True(t, resource.MustParse("500m").Equal(result.MaxFreeCPU))
+	require.Len(t, result.Nodes, 1)
+	assert.True(t, resource.MustParse("2").Equal(result.Nodes[0].AllocatableCPU))
+	assert.True(t, resource.MustParse("500m").Equal(result.Nodes[0].FreeCPU))
+	assert.True(t, resource.MustParse("3Gi").Equal(result.Nodes[0].FreeMemory))
+
+	mockFetcher.AssertExpectations(t)
+}
+
+func TestAnalyzePodSchedulability_FetchScheduledPodsError(t *testing.T) {
+	mockFetcher := &MockFetcher{}
+
+	expectedError := errors.New("failed to fetch scheduled pods")
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return([]types.PodInfo{}, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return([]types.NodeInfo{}, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return([]types.PodInfo(nil), expectedError)
+
+	analyzer := NewAnalyzer(mockFetcher)
+	ctx := context.Background()
+
+	results, err := analyzer.AnalyzePodSchedulability(ctx, "default", false)
+
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.Contains(t, err.Error(), "failed to fetch scheduled pods")
+
+	mockFetcher.AssertExpectations(t)
+}
+
 func TestAnalyzePodSchedulability_FetchPodsError(t *testing.T) {
 	mockFetcher := &MockFetcher{}
 
@@ -348,6 +446,41 @@ func TestAnalyzeSinglePod(t *testing.T) {
 				MaxAvailableMemory: resource.MustParse("4Gi"),
 			},
 		},
+		{
+			name: "unschedulable pod - cpu and memory maxima on different nodes",
+			pod: types.PodInfo{
+				Name:           "split-maxima-pod",
+				Namespace:      "default",
+				RequestsCPU:    resource.MustParse("8"),
+				RequestsMemory: resource.MustParse("60Gi"),
+			},
+			nodes: []types.NodeInfo{
+				{
+					Name:              "cpu-node",
+					AllocatableCPU:    resource.MustParse("16"),
+					AllocatableMemory: resource.MustParse("32Gi"),
+				},
+				{
+					Name:              "memory-node",
+					AllocatableCPU:    resource.MustParse("4"),
+					AllocatableMemory: resource.MustParse("64Gi"),
+				},
+			},
+			includeLimits: false,
+			expectedResult: types.AnalysisResult{
+				Pod: types.PodInfo{
+					Name:           "split-maxima-pod",
+					Namespace:      "default",
+					RequestsCPU:    resource.MustParse("8"),
+					RequestsMemory: resource.MustParse("60Gi"),
+				},
+				IsSchedulable:      false,
+				Reason:             "requests.cpu = 8 and requests.memory = 60Gi do not fit together on any single node (max free CPU: 16 and max free memory: 64Gi are on different nodes)",
+				Suggestion:         "Lower requests.cpu or requests.memory so that both fit within one node, or add a node with at least 8 CPU and 60Gi memory free",
+				MaxAvailableCPU:    resource.MustParse("16"),
+				MaxAvailableMemory: resource.MustParse("64Gi"),
+			},
+		},
 	}
 
 	analyzer := &Analyzer{}
@@ -367,6 +500,68 @@ func TestAnalyzeSinglePod(t *testing.T) {
 	}
 }
 
+func TestEmptyDirMemoryWarning(t *testing.T) {
+	emptyDir := func(size, limit string) types.PodInfo {
+		quantity := resource.MustParse(size)
+		pod := types.PodInfo{Name: "cache", Namespace: "default", MemoryBackedEmptyDir: &quantity}
+		if limit != "" {
+			pod.LimitsMemory = resource.MustParse(limit)
+		}
+		return pod
+	}
+	nodeFits := []types.NodeFit{
+		{Name: "node-1", FreeMemory: resource.MustParse("16Gi")},
+		{Name: "node-2", FreeMemory: resource.MustParse("4Gi"), Fits: true},
+	}
+
+	tests := []struct {
+		name            string
+		pod             types.PodInfo
+		nodeFits        []types.NodeFit
+		expectedWarning string
+	}{
+		{
+			name:     "no memory-backed emptyDir",
+			pod:      types.PodInfo{Name: "app", Namespace: "default"},
+			nodeFits: nodeFits,
+		},
+		{
+			name:     "within limit and free memory",
+			pod:      emptyDir("2Gi", "4Gi"),
+			nodeFits: nodeFits,
+		},
+		{
+			name:     "exceeds the memory limit",
+			pod:      emptyDir("2Gi", "1Gi"),
+			nodeFits: nodeFits,
+			expectedWarning: "memory-backed emptyDir volumes may hold 2Gi, more than the pod's memory limit of 1Gi; " +
+				"filling them gets its containers OOM-killed",
+		},
+		{
+			name:     "exceeds the free memory of the fitting nodes",
+			pod:      emptyDir("8Gi", ""),
+			nodeFits: nodeFits,
+			expectedWarning: "memory-backed emptyDir volumes may hold 8Gi, more than the free memory of any node " +
+				"the pod can be placed on (max: 4Gi); filling them can exhaust the node's memory",
+		},
+		{
+			name:     "candidate nodes used when none fits",
+			pod:      emptyDir("8Gi", ""),
+			nodeFits: nodeFits[:1],
+		},
+		{
+			name: "no candidate nodes",
+			pod:  emptyDir("8Gi", ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedWarning, emptyDirMemoryWarning(tt.pod, tt.nodeFits))
+		})
+	}
+}
+
 func TestFindMaxAvailableResources(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -445,6 +640,132 @@ func TestFindMaxAvailableResources(t *testing.T) {
 	}
 }
 
+func TestEvaluateNodeFits(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{
+			Name:              "cpu-node",
+			AllocatableCPU:    resource.MustParse("16"),
+			AllocatableMemory: resource.MustParse("32Gi"),
+		},
+		{
+			Name:              "memory-node",
+			AllocatableCPU:    resource.MustParse("4"),
+			AllocatableMemory: resource.MustParse("64Gi"),
+		},
+		{
+			Name:              "balanced-node",
+			AllocatableCPU:    resource.MustParse("8"),
+			AllocatableMemory: resource.MustParse("32Gi"),
+			Allocatable: corev1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("1"),
+			},
+		},
+		{
+			Name:              "busy-node",
+			AllocatableCPU:    resource.MustParse("16"),
+			AllocatableMemory: resource.MustParse("64Gi"),
+			RequestedCPU:      resource.MustParse("15"),
+			RequestedMemory:   resource.MustParse("60Gi"),
+		},
+	}
+
+	tests := []struct {
+		name          string
+		podCPU        resource.Quantity
+		podMemory     resource.Quantity
+		extended      corev1.ResourceList
+		expectedNodes []string
+	}{
+		{
+			name:          "fits on every node",
+			podCPU:        resource.MustParse("1"),
+			podMemory:     resource.MustParse("1Gi"),
+			expectedNodes: []string{"cpu-node", "memory-node", "balanced-node", "busy-node"},
+		},
+		{
+			name:          "fits only where both dimensions are satisfied",
+			podCPU:        resource.MustParse("8"),
+			podMemory:     resource.MustParse("16Gi"),
+			expectedNodes: []string{"cpu-node", "balanced-node"},
+		},
+		{
+			name:          "cpu and memory maxima on different nodes",
+			podCPU:        resource.MustParse("8"),
+			podMemory:     resource.MustParse("60Gi"),
+			expectedNodes: nil,
+		},
+		{
+			name:          "extended resource only on one node",
+			podCPU:        resource.MustParse("1"),
+			podMemory:     resource.MustParse("1Gi"),
+			extended:      corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+			expectedNodes: []string{"balanced-node"},
+		},
+	}
+
+	analyzer := &Analyzer{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeFits := analyzer.evaluateNodeFits(nodes, tt.podCPU, tt.podMemory, tt.extended)
+
+			assert.Len(t, nodeFits, len(nodes))
+			assert.Equal(t, tt.expectedNodes, fittingNodeNames(nodeFits))
+		})
+	}
+}
+
+func TestApplyScheduledPodRequests(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{
+			Name:              "node1",
+			AllocatableCPU:    resource.MustParse("4"),
+			AllocatableMemory: resource.MustParse("8Gi"),
+		},
+		{
+			Name:              "node2",
+			AllocatableCPU:    resource.MustParse("2"),
+			AllocatableMemory: resource.MustParse("4Gi"),
+		},
+	}
+
+	scheduledPods := []types.PodInfo{
+		{
+			Name:           "pod-a",
+			NodeName:       "node1",
+			RequestsCPU:    resource.MustParse("500m"),
+			RequestsMemory: resource.MustParse("1Gi"),
+		},
+		{
+			Name:           "pod-b",
+			NodeName:       "node1",
+			RequestsCPU:    resource.MustParse("1"),
+			RequestsMemory: resource.MustParse("2Gi"),
+		},
+		{
+			Name:           "pod-on-unknown-node",
+			NodeName:       "node3",
+			RequestsCPU:    resource.MustParse("1"),
+			RequestsMemory: resource.MustParse("1Gi"),
+		},
+	}
+
+	analyzer := &Analyzer{}
+	result := analyzer.applyScheduledPodRequests(nodes, scheduledPods)
+
+	require.Len(t, result, 2)
+	assert.True(t, resource.MustParse("1500m").Equal(result[0].RequestedCPU))
+	assert.True(t, resource.MustParse("3Gi").Equal(result[0].RequestedMemory))
+	assert.True(t, result[1].RequestedCPU.IsZero())
+	assert.True(t, result[1].RequestedMemory.IsZero())
+	assert.Equal(t, int64(2), result[0].PodCount)
+	assert.Equal(t, int64(0), result[1].PodCount)
+
+	freeCPU, freeMemory := freeResources(result[0])
+	assert.True(t, resource.MustParse("2500m").Equal(freeCPU))
+	assert.True(t, resource.MustParse("5Gi").Equal(freeMemory))
+}
+
 func TestEvaluateResourceConstraints(t *testing.T) {
 	analyzer := &Analyzer{}
 	ctx := context.Background()
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+)
+
+// waitingSuggestions maps container waiting reasons to what usually resolves them.
+var waitingSuggestions = map[string]string{
+	"ImagePullBackOff":           "Check the image name and tag, registry availability and imagePullSecrets",
+	"ErrImagePull":               "Check the image name and tag, registry availability and imagePullSecrets",
+	"InvalidImageName":           "Fix the image reference in the pod spec",
+	"CreateContainerConfigError": "Check that the ConfigMaps and Secrets referenced by the container exist",
+	"CreateContainerError":       "Check the container's command, mounts and security context",
+	"CrashLoopBackOff":           "Check the logs of the crashing container",
+	"ContainerCreating":          "Check the pod's events for volume mount, CNI or sandbox errors (kubectl describe pod)",
+	"PodInitializing":            "Check the logs of the init containers that have not completed",
+}
+
+// analyzeBoundPod explains a Pending pod that the scheduler has already bound to a node.
+// Capacity checks do not apply to it; it is waiting for its containers to start, so the
+// containers' waiting reasons are reported instead.
+//
+// Parameters:
+//   - pod: The pending pod with NodeName set
+//
+// Returns:
+//   - types.AnalysisResult: A result in the BoundNotRunning category
+func analyzeBoundPod(pod types.PodInfo) types.AnalysisResult {
+	result := types.AnalysisResult{
+		Pod:           pod,
+		Category:      types.PendingCategoryBoundNotRunning,
+		IsSchedulable: true,
+	}
+
+	nodes := []string{pod.NodeName}
+	if len(pod.WaitingContainers) == 0 {
+		result.Reasons = []types.ReasonDetail{messageDetail(types.ReasonCodeContainersWaiting,
+			fmt.Sprintf("bound to node %s; waiting for its containers to start", pod.NodeName), nodes, nil)}
+		result.Reason = renderReasons(result.Reasons)
+		result.Suggestion = waitingSuggestions["ContainerCreating"]
+		return result
+	}
+
+	var waiting []string
+	var suggestions []string
+	for _, container := range pod.WaitingContainers {
+		entry := fmt.Sprintf("container %s is %s", container.Container, container.Reason)
+		if container.Message != "" {
+			entry += fmt.Sprintf(" (%s)", container.Message)
+		}
+		waiting = append(waiting, entry)
+
+		suggestion, ok := waitingSuggestions[container.Reason]
+		if !ok {
+			suggestion = "Check the pod's events (kubectl describe pod)"
+		}
+		if !containsString(suggestions, suggestion) {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	result.Reasons = []types.ReasonDetail{messageDetail(types.ReasonCodeContainersWaiting,
+		fmt.Sprintf("bound to node %s but not running: %s", pod.NodeName, strings.Join(waiting, "; ")), nodes, waiting)}
+	result.Reason = renderReasons(result.Reasons)
+	result.Suggestion = strings.Join(suggestions, "; ")
+	return result
+}
+
+// unscheduledPods returns the pending pods that are not bound to a node yet.
+func unscheduledPods(pods []types.PodInfo) []types.PodInfo {
+	unscheduled := make([]types.PodInfo, 0, len(pods))
+	for _, pod := range pods {
+		if pod.NodeName == "" {
+			unscheduled = append(unscheduled, pod)
+		}
+	}
+	return unscheduled
+}
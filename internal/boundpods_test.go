@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestAnalyzeBoundPod(t *testing.T) {
+	tests := []struct {
+		name               string
+		waiting            []types.ContainerWaiting
+		expectedReason     string
+		expectedSuggestion string
+	}{
+		{
+			name: "image pull back-off",
+			waiting: []types.ContainerWaiting{
+				{Container: "app", Reason: "ImagePullBackOff", Message: `Back-off pulling image "registry.example.com/app:v2"`},
+			},
+			expectedReason: `bound to node node-1 but not running: container app is ImagePullBackOff ` +
+				`(Back-off pulling image "registry.example.com/app:v2")`,
+			expectedSuggestion: "Check the image name and tag, registry availability and imagePullSecrets",
+		},
+		{
+			name: "missing secret and init container",
+			waiting: []types.ContainerWaiting{
+				{Container: "migrate", Reason: "CreateContainerConfigError", Message: `secret "db" not found`},
+				{Container: "app", Reason: "PodInitializing"},
+			},
+			expectedReason: `bound to node node-1 but not running: container migrate is CreateContainerConfigError ` +
+				`(secret "db" not found); container app is PodInitializing`,
+			expectedSuggestion: "Check that the ConfigMaps and Secrets referenced by the container exist; " +
+				"Check the logs of the init containers that have not completed",
+		},
+		{
+			name:               "unknown reason",
+			waiting:            []types.ContainerWaiting{{Container: "app", Reason: "RunContainerError"}},
+			expectedReason:     "bound to node node-1 but not running: container app is RunContainerError",
+			expectedSuggestion: "Check the pod's events (kubectl describe pod)",
+		},
+		{
+			name:           "no container statuses yet",
+			expectedReason: "bound to node node-1; waiting for its containers to start",
+			expectedSuggestion: "Check the pod's events for volume mount, CNI or sandbox errors " +
+				"(kubectl describe pod)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := types.PodInfo{Name: "web-0", Namespace: "default", NodeName: "node-1", WaitingContainers: tt.waiting}
+
+			result := analyzeBoundPod(pod)
+
+			assert.Equal(t, types.PendingCategoryBoundNotRunning, result.Category)
+			assert.True(t, result.IsSchedulable)
+			assert.Equal(t, tt.expectedReason, result.Reason)
+			assert.Equal(t, tt.expectedSuggestion, result.Suggestion)
+		})
+	}
+}
+
+func TestAnalyzePodSchedulability_BoundPodsSkipCapacityChecks(t *testing.T) {
+	pods := []types.PodInfo{
+		{
+			Name:           "huge-bound",
+			Namespace:      "default",
+			NodeName:       "node-1",
+			RequestsCPU:    resource.MustParse("64"),
+			RequestsMemory: resource.MustParse("512Gi"),
+			WaitingContainers: []types.ContainerWaiting{
+				{Container: "app", Reason: "ImagePullBackOff"},
+			},
+		},
+		{
+			Name:           "small-unscheduled",
+			Namespace:      "default",
+			RequestsCPU:    resource.MustParse("100m"),
+			RequestsMemory: resource.MustParse("128Mi"),
+		},
+	}
+	nodes := []types.NodeInfo{hostnameNode("node-1", "a")}
+
+	mockFetcher := &MockFetcher{}
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return(pods, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return(nodes, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return([]types.PodInfo{}, nil)
+
+	results, err := NewAnalyzer(mockFetcher).AnalyzePodSchedulability(context.Background(), "default", false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, types.PendingCategoryBoundNotRunning, results[0].Category)
+	assert.Equal(t, "bound to node node-1 but not running: container app is ImagePullBackOff", results[0].Reason)
+	assert.Empty(t, results[0].Nodes)
+	assert.Equal(t, types.PendingCategoryUnscheduled, results[1].Category)
+	assert.True(t, results[1].IsSchedulable)
+	mockFetcher.AssertExpectations(t)
+}
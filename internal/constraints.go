@@ -0,0 +1,523 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// nodeExclusion records the nodes that a single hard scheduling constraint removed
+// from a pod's candidate set, together with the wording used to explain it.
+type nodeExclusion struct {
+	// constraint is a short name of the constraint, e.g. "untolerated taints"
+	constraint string
+	// code identifies the constraint in machine-readable results
+	code types.ReasonCode
+	// nodes are the nodes removed by this constraint
+	nodes []types.NodeInfo
+	// details lists the constraint-specific items that caused the exclusion
+	details []string
+	// reason explains the failure when this constraint alone excluded every node
+	reason string
+	// summary explains the exclusion when other constraints also removed nodes
+	summary string
+	// suggestion describes how to relax the constraint
+	suggestion string
+	// predicates are the scheduler-style reasons each excluded node failed, keyed by node name
+	predicates map[string][]string
+}
+
+// exclude records a node removed by the constraint together with the reasons it failed, in
+// the wording the scheduler uses in its "0/N nodes are available" message.
+func (e *nodeExclusion) exclude(node types.NodeInfo, predicates ...string) {
+	e.nodes = append(e.nodes, node)
+	if e.predicates == nil {
+		e.predicates = make(map[string][]string)
+	}
+	e.predicates[node.Name] = append(e.predicates[node.Name], predicates...)
+}
+
+// filterByTaints removes nodes carrying NoSchedule or NoExecute taints that the pod
+// does not tolerate. PreferNoSchedule taints are ignored because the scheduler treats
+// them as a soft preference.
+//
+// Parameters:
+//   - pod: The pod whose tolerations are checked
+//   - nodes: Candidate nodes to filter
+//
+// Returns:
+//   - []types.NodeInfo: Nodes whose taints are all tolerated
+//   - nodeExclusion: The excluded nodes and the distinct untolerated taints
+func filterByTaints(pod types.PodInfo, nodes []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+	exclusion := nodeExclusion{constraint: "untolerated taints", code: types.ReasonCodeTaintNotTolerated}
+	kept := make([]types.NodeInfo, 0, len(nodes))
+	seen := make(map[string]bool)
+
+	for _, node := range nodes {
+		untolerated := untoleratedTaints(node.Taints, pod.Tolerations)
+		if len(untolerated) == 0 {
+			kept = append(kept, node)
+			continue
+		}
+
+		exclusion.exclude(node, fmt.Sprintf("node(s) had untolerated taint {%s: %s}",
+			untolerated[0].Key, untolerated[0].Value))
+		for _, taint := range untolerated {
+			formatted := formatTaint(taint)
+			if !seen[formatted] {
+				seen[formatted] = true
+				exclusion.details = append(exclusion.details, formatted)
+			}
+		}
+	}
+
+	sort.Strings(exclusion.details)
+	taintList := strings.Join(exclusion.details, ", ")
+	exclusion.reason = fmt.Sprintf("all %d node(s) have taints the pod does not tolerate: %s",
+		len(exclusion.nodes), taintList)
+	exclusion.summary = fmt.Sprintf("%d node(s) have untolerated taints (%s)",
+		len(exclusion.nodes), taintList)
+	exclusion.suggestion = fmt.Sprintf("Add tolerations for %s to the pod, or remove the taints from suitable nodes",
+		taintList)
+
+	return kept, exclusion
+}
+
+// filterByNodeAvailability removes cordoned nodes and nodes whose Ready condition is False
+// or Unknown. As in the scheduler, a pod that tolerates the node.kubernetes.io/unschedulable
+// or node.kubernetes.io/not-ready taint may still use such nodes. Nodes that do not report
+// a Ready condition are treated as ready.
+//
+// Parameters:
+//   - pod: The pod whose tolerations are checked
+//   - nodes: Candidate nodes to filter
+//
+// Returns:
+//   - []types.NodeInfo: Nodes that are schedulable and ready
+//   - nodeExclusion: The excluded nodes, with details such as "node1 (cordoned)"
+func filterByNodeAvailability(pod types.PodInfo, nodes []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+	exclusion := nodeExclusion{constraint: "cordoned or NotReady state", code: types.ReasonCodeNodeUnavailable}
+	kept := make([]types.NodeInfo, 0, len(nodes))
+
+	for _, node := range nodes {
+		var problems, predicates []string
+		if node.Unschedulable && !toleratesAny(pod.Tolerations, corev1.Taint{
+			Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}) {
+			problems = append(problems, "cordoned")
+			predicates = append(predicates, "node(s) were unschedulable")
+		}
+		// The node lifecycle controller taints nodes whose Ready condition is False as
+		// not-ready, and nodes whose Ready condition is Unknown as unreachable.
+		if status := nodeReadyStatus(node); status != corev1.ConditionTrue {
+			taintKey, problem := corev1.TaintNodeUnreachable, "Ready="+string(status)
+			if status == corev1.ConditionFalse {
+				taintKey, problem = corev1.TaintNodeNotReady, "NotReady"
+			}
+			if !toleratesAny(pod.Tolerations, corev1.Taint{Key: taintKey, Effect: corev1.TaintEffectNoSchedule}) {
+				problems = append(problems, problem)
+				predicates = append(predicates, fmt.Sprintf("node(s) had untolerated taint {%s: }", taintKey))
+			}
+		}
+
+		if len(problems) == 0 {
+			kept = append(kept, node)
+			continue
+		}
+
+		exclusion.exclude(node, predicates...)
+		exclusion.details = append(exclusion.details,
+			fmt.Sprintf("%s (%s)", node.Name, strings.Join(problems, ", ")))
+	}
+
+	nodeList := strings.Join(exclusion.details, ", ")
+	exclusion.reason = fmt.Sprintf("all %d node(s) are cordoned or not ready: %s", len(exclusion.nodes), nodeList)
+	exclusion.summary = fmt.Sprintf("%d node(s) are cordoned or not ready (%s)", len(exclusion.nodes), nodeList)
+	exclusion.suggestion = "Uncordon or repair the nodes, add nodes, or rerun with --include-unavailable-nodes to evaluate them anyway"
+
+	return kept, exclusion
+}
+
+// withAvailabilityTolerations returns a copy of the pod that also tolerates the taints the
+// node controllers put on cordoned, not ready and unreachable nodes, so that such nodes are
+// evaluated like any other node.
+func withAvailabilityTolerations(pod types.PodInfo) types.PodInfo {
+	tolerations := make([]corev1.Toleration, 0, len(pod.Tolerations)+3)
+	tolerations = append(tolerations, pod.Tolerations...)
+	for _, key := range []string{corev1.TaintNodeUnschedulable, corev1.TaintNodeNotReady, corev1.TaintNodeUnreachable} {
+		tolerations = append(tolerations, corev1.Toleration{Key: key, Operator: corev1.TolerationOpExists})
+	}
+	pod.Tolerations = tolerations
+	return pod
+}
+
+// nodeReadyStatus returns the status of the node's Ready condition, or ConditionTrue when
+// the node does not report one.
+func nodeReadyStatus(node types.NodeInfo) corev1.ConditionStatus {
+	for _, condition := range node.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status
+		}
+	}
+	return corev1.ConditionTrue
+}
+
+// toleratesAny reports whether any of the tolerations tolerates the taint.
+func toleratesAny(tolerations []corev1.Toleration, taint corev1.Taint) bool {
+	for _, toleration := range tolerations {
+		if toleratesTaint(toleration, taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByPodCapacity removes nodes that already run as many pods as their allocatable
+// pod count allows. Nodes that do not report a pod limit are kept.
+//
+// Parameters:
+//   - nodes: Candidate nodes to filter
+//
+// Returns:
+//   - []types.NodeInfo: Nodes with at least one free pod slot
+//   - nodeExclusion: The excluded nodes, with their names as details
+func filterByPodCapacity(nodes []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+	exclusion := nodeExclusion{constraint: "pod limit", code: types.ReasonCodeTooManyPods}
+	kept := make([]types.NodeInfo, 0, len(nodes))
+
+	for _, node := range nodes {
+		if node.AllocatablePods > 0 && node.PodCount >= node.AllocatablePods {
+			exclusion.exclude(node, "Too many pods")
+			exclusion.details = append(exclusion.details, node.Name)
+			continue
+		}
+		kept = append(kept, node)
+	}
+
+	nodeList := strings.Join(exclusion.details, ", ")
+	exclusion.reason = fmt.Sprintf("Too many pods: all %d node(s) have reached their allocatable pod limit (%s)",
+		len(exclusion.nodes), nodeList)
+	exclusion.summary = fmt.Sprintf("%d node(s) have reached their allocatable pod limit (%s)",
+		len(exclusion.nodes), nodeList)
+	exclusion.suggestion = "Scale down other workloads, raise the kubelet maxPods setting, or add nodes"
+
+	return kept, exclusion
+}
+
+// untoleratedTaints returns the NoSchedule and NoExecute taints that none of the
+// given tolerations tolerate.
+func untoleratedTaints(taints []corev1.Taint, tolerations []corev1.Toleration) []corev1.Taint {
+	var untolerated []corev1.Taint
+
+	for _, taint := range taints {
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+
+		tolerated := false
+		for _, toleration := range tolerations {
+			if toleratesTaint(toleration, taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			untolerated = append(untolerated, taint)
+		}
+	}
+
+	return untolerated
+}
+
+// toleratesTaint reports whether a toleration matches a taint, following the same rules
+// as the Kubernetes scheduler: an empty effect matches every effect, an empty key with
+// the Exists operator matches every key, and the Equal operator (the default) also
+// requires the values to match.
+func toleratesTaint(toleration corev1.Toleration, taint corev1.Taint) bool {
+	if toleration.Effect != "" && toleration.Effect != taint.Effect {
+		return false
+	}
+
+	if toleration.Key != taint.Key && (toleration.Key != "" || toleration.Operator != corev1.TolerationOpExists) {
+		return false
+	}
+
+	switch toleration.Operator {
+	case corev1.TolerationOpExists:
+		return true
+	case "", corev1.TolerationOpEqual:
+		return toleration.Value == taint.Value
+	default:
+		return false
+	}
+}
+
+// formatTaint renders a taint in the key=value:Effect form used by kubectl.
+func formatTaint(taint corev1.Taint) string {
+	if taint.Value == "" {
+		return fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect)
+}
+
+// constraintReason explains why no node is left after applying hard scheduling
+// constraints. When a single constraint excluded every node its dedicated wording is
+// used; otherwise the per-constraint summaries are combined.
+//
+// Parameters:
+//   - exclusions: The exclusions produced while filtering candidate nodes
+//
+// Returns:
+//   - []types.ReasonDetail: One reason per constraint that excluded nodes
+//   - string: A suggestion for resolving the issue
+func constraintReason(exclusions []nodeExclusion) ([]types.ReasonDetail, string) {
+	var active []nodeExclusion
+	for _, exclusion := range exclusions {
+		if len(exclusion.nodes) > 0 {
+			active = append(active, exclusion)
+		}
+	}
+
+	reasons := make([]types.ReasonDetail, 0, len(active))
+	suggestions := make([]string, 0, len(active))
+	for _, exclusion := range active {
+		message := exclusion.summary
+		if len(active) == 1 {
+			message = exclusion.reason
+		}
+		reasons = append(reasons, messageDetail(exclusion.code, message, nodeInfoNames(exclusion.nodes), exclusion.details))
+		suggestions = append(suggestions, exclusion.suggestion)
+	}
+
+	return reasons, strings.Join(suggestions, "; ")
+}
+
+// nodeInfoNames returns the names of the nodes, preserving order.
+func nodeInfoNames(nodes []types.NodeInfo) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
+
+// excludedFitReasons names, per constraint, the nodes that have enough free resources for
+// the pod but were removed by that constraint. It returns nil when no excluded node would fit.
+func excludedFitReasons(exclusions []nodeExclusion, podCPU, podMemory resource.Quantity,
+	extended corev1.ResourceList) []types.ReasonDetail {
+	var reasons []types.ReasonDetail
+
+	for _, exclusion := range exclusions {
+		var fitting []string
+		for _, node := range exclusion.nodes {
+			if len(insufficientResources(node, podCPU, podMemory, extended)) == 0 {
+				fitting = append(fitting, node.Name)
+			}
+		}
+		if len(fitting) > 0 {
+			reasons = append(reasons, messageDetail(types.ReasonCodeFittingNodesExcluded,
+				fmt.Sprintf("node(s) %s have enough free resources but are excluded by %s",
+					strings.Join(fitting, ", "), exclusion.constraint),
+				fitting, []string{string(exclusion.code)}))
+		}
+	}
+
+	return reasons
+}
+
+// filterByNodeAffinity removes nodes that do not satisfy the pod's
+// requiredDuringSchedulingIgnoredDuringExecution node affinity. Preferred terms are
+// ignored because they only influence scoring.
+//
+// Parameters:
+//   - pod: The pod whose node affinity is checked
+//   - nodes: Candidate nodes to filter
+//
+// Returns:
+//   - []types.NodeInfo: Nodes matching the required node affinity
+//   - nodeExclusion: The excluded nodes
+func filterByNodeAffinity(pod types.PodInfo, nodes []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+	exclusion := nodeExclusion{constraint: "node affinity", code: types.ReasonCodeNodeAffinityMismatch}
+	kept := make([]types.NodeInfo, 0, len(nodes))
+
+	var required *corev1.NodeSelector
+	if pod.NodeAffinity != nil {
+		required = pod.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	}
+
+	for _, node := range nodes {
+		if required == nil || nodeSelectorMatches(required, node) {
+			kept = append(kept, node)
+		} else {
+			exclusion.exclude(node, nodeAffinityPredicate)
+		}
+	}
+
+	exclusion.reason = fmt.Sprintf("no node matches affinity (%d node(s) checked against requiredDuringSchedulingIgnoredDuringExecution)",
+		len(exclusion.nodes))
+	exclusion.summary = fmt.Sprintf("%d node(s) do not match the required node affinity", len(exclusion.nodes))
+	exclusion.suggestion = "Relax the pod's required node affinity or label nodes so that they match it"
+
+	return kept, exclusion
+}
+
+// nodeSelectorMatches reports whether a node satisfies a NodeSelector. The terms are
+// ORed together; within a term all match expressions and match fields must hold. A term
+// without any requirement matches no node, as in the Kubernetes scheduler.
+func nodeSelectorMatches(selector *corev1.NodeSelector, node types.NodeInfo) bool {
+	for _, term := range selector.NodeSelectorTerms {
+		if nodeSelectorTermMatches(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeSelectorTermMatches(term corev1.NodeSelectorTerm, node types.NodeInfo) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+
+	for _, requirement := range term.MatchExpressions {
+		value, exists := node.Labels[requirement.Key]
+		if !nodeSelectorRequirementMatches(requirement, value, exists) {
+			return false
+		}
+	}
+
+	for _, requirement := range term.MatchFields {
+		// metadata.name is the only field supported by the scheduler.
+		if requirement.Key != "metadata.name" {
+			return false
+		}
+		if requirement.Operator != corev1.NodeSelectorOpIn && requirement.Operator != corev1.NodeSelectorOpNotIn {
+			return false
+		}
+		if !nodeSelectorRequirementMatches(requirement, node.Name, true) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nodeSelectorRequirementMatches evaluates a single requirement against a label value.
+// exists reports whether the label is present on the node at all.
+func nodeSelectorRequirementMatches(requirement corev1.NodeSelectorRequirement, value string, exists bool) bool {
+	switch requirement.Operator {
+	case corev1.NodeSelectorOpIn:
+		return exists && containsString(requirement.Values, value)
+	case corev1.NodeSelectorOpNotIn:
+		return !exists || !containsString(requirement.Values, value)
+	case corev1.NodeSelectorOpExists:
+		return exists
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !exists || len(requirement.Values) != 1 {
+			return false
+		}
+		nodeValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		requiredValue, err := strconv.ParseInt(requirement.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if requirement.Operator == corev1.NodeSelectorOpGt {
+			return nodeValue > requiredValue
+		}
+		return nodeValue < requiredValue
+	default:
+		return false
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByNodeSelector removes nodes whose labels do not contain every key/value pair of
+// the pod's spec.nodeSelector. Selector entries that no node in the cluster carries at all
+// are recorded in the exclusion details, since they usually point to a typo or a missing
+// node pool. Entries carried only by nodes an earlier filter removed, e.g. cordoned nodes,
+// are not reported as such.
+//
+// Parameters:
+//   - pod: The pod whose nodeSelector is checked
+//   - nodes: Candidate nodes to filter
+//   - allNodes: Every node in the cluster, used to find the entries no node carries
+//
+// Returns:
+//   - []types.NodeInfo: Nodes matching the nodeSelector
+//   - nodeExclusion: The excluded nodes and the selector entries that matched zero nodes
+func filterByNodeSelector(pod types.PodInfo, nodes, allNodes []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+	exclusion := nodeExclusion{constraint: "nodeSelector", code: types.ReasonCodeNodeSelectorMismatch}
+	kept := make([]types.NodeInfo, 0, len(nodes))
+
+	for _, node := range nodes {
+		if labelsMatchSelector(node.Labels, pod.NodeSelector) {
+			kept = append(kept, node)
+		} else {
+			exclusion.exclude(node, nodeAffinityPredicate)
+		}
+	}
+
+	keys := make([]string, 0, len(pod.NodeSelector))
+	for key := range pod.NodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	selectorEntries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		entry := fmt.Sprintf("%s=%s", key, pod.NodeSelector[key])
+		selectorEntries = append(selectorEntries, entry)
+
+		matched := false
+		for _, node := range allNodes {
+			if value, ok := node.Labels[key]; ok && value == pod.NodeSelector[key] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			exclusion.details = append(exclusion.details, entry)
+		}
+	}
+
+	if len(exclusion.details) > 0 {
+		unmatched := strings.Join(exclusion.details, ", ")
+		exclusion.reason = fmt.Sprintf("no node matches nodeSelector: %s matched zero nodes", unmatched)
+		exclusion.summary = fmt.Sprintf("%d node(s) do not match the nodeSelector (%s matched zero nodes)",
+			len(exclusion.nodes), unmatched)
+		exclusion.suggestion = fmt.Sprintf("Label nodes with %s or remove those entries from the pod's nodeSelector", unmatched)
+	} else {
+		exclusion.reason = fmt.Sprintf("no node matches all nodeSelector labels (%s)", strings.Join(selectorEntries, ", "))
+		exclusion.summary = fmt.Sprintf("%d node(s) do not match the nodeSelector", len(exclusion.nodes))
+		exclusion.suggestion = "Adjust the pod's nodeSelector or label nodes so that one node carries all of its labels"
+	}
+
+	return kept, exclusion
+}
+
+// labelsMatchSelector reports whether labels contain every key/value pair of selector.
+// An empty selector matches any labels.
+func labelsMatchSelector(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if labelValue, ok := labels[key]; !ok || labelValue != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,773 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestToleratesTaint(t *testing.T) {
+	taint := corev1.Taint{
+		Key:    "dedicated",
+		Value:  "gpu",
+		Effect: corev1.TaintEffectNoSchedule,
+	}
+
+	tests := []struct {
+		name       string
+		toleration corev1.Toleration
+		expected   bool
+	}{
+		{
+			name: "equal operator with matching key, value and effect",
+			toleration: corev1.Toleration{
+				Key:      "dedicated",
+				Operator: corev1.TolerationOpEqual,
+				Value:    "gpu",
+				Effect:   corev1.TaintEffectNoSchedule,
+			},
+			expected: true,
+		},
+		{
+			name: "empty operator defaults to equal",
+			toleration: corev1.Toleration{
+				Key:   "dedicated",
+				Value: "gpu",
+			},
+			expected: true,
+		},
+		{
+			name: "equal operator with different value",
+			toleration: corev1.Toleration{
+				Key:      "dedicated",
+				Operator: corev1.TolerationOpEqual,
+				Value:    "cpu",
+			},
+			expected: false,
+		},
+		{
+			name: "exists operator ignores value",
+			toleration: corev1.Toleration{
+				Key:      "dedicated",
+				Operator: corev1.TolerationOpExists,
+			},
+			expected: true,
+		},
+		{
+			name: "exists operator with empty key tolerates everything",
+			toleration: corev1.Toleration{
+				Operator: corev1.TolerationOpExists,
+			},
+			expected: true,
+		},
+		{
+			name: "empty key with equal operator does not match",
+			toleration: corev1.Toleration{
+				Operator: corev1.TolerationOpEqual,
+				Value:    "gpu",
+			},
+			expected: false,
+		},
+		{
+			name: "different effect",
+			toleration: corev1.Toleration{
+				Key:      "dedicated",
+				Operator: corev1.TolerationOpExists,
+				Effect:   corev1.TaintEffectNoExecute,
+			},
+			expected: false,
+		},
+		{
+			name: "different key",
+			toleration: corev1.Toleration{
+				Key:      "team",
+				Operator: corev1.TolerationOpExists,
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, toleratesTaint(tt.toleration, taint))
+		})
+	}
+}
+
+func TestFilterByTaints(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{
+			Name: "plain-node",
+		},
+		{
+			Name: "gpu-node",
+			Taints: []corev1.Taint{
+				{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+		{
+			Name: "soft-tainted-node",
+			Taints: []corev1.Taint{
+				{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+			},
+		},
+		{
+			Name: "draining-node",
+			Taints: []corev1.Taint{
+				{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute},
+			},
+		},
+	}
+
+	t.Run("pod without tolerations", func(t *testing.T) {
+		kept, exclusion := filterByTaints(types.PodInfo{Name: "pod"}, nodes)
+
+		assert.Equal(t, []string{"plain-node", "soft-tainted-node"}, nodeInfoNames(kept))
+		assert.Len(t, exclusion.nodes, 2)
+		assert.Equal(t, []string{"dedicated=gpu:NoSchedule", "node.kubernetes.io/unreachable:NoExecute"}, exclusion.details)
+	})
+
+	t.Run("pod tolerating the gpu taint", func(t *testing.T) {
+		pod := types.PodInfo{
+			Name: "pod",
+			Tolerations: []corev1.Toleration{
+				{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+			},
+		}
+
+		kept, exclusion := filterByTaints(pod, nodes)
+
+		assert.Equal(t, []string{"plain-node", "gpu-node", "soft-tainted-node"}, nodeInfoNames(kept))
+		assert.Equal(t, []string{"node.kubernetes.io/unreachable:NoExecute"}, exclusion.details)
+	})
+}
+
+func TestAnalyzeSinglePod_Taints(t *testing.T) {
+	gpuTaint := corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}
+
+	t.Run("all nodes tainted", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			{
+				Name:              "gpu-node-1",
+				AllocatableCPU:    resource.MustParse("8"),
+				AllocatableMemory: resource.MustParse("32Gi"),
+				Taints:            []corev1.Taint{gpuTaint},
+			},
+			{
+				Name:              "gpu-node-2",
+				AllocatableCPU:    resource.MustParse("8"),
+				AllocatableMemory: resource.MustParse("32Gi"),
+				Taints:            []corev1.Taint{gpuTaint},
+			},
+		}
+		pod := types.PodInfo{
+			Name:           "web",
+			Namespace:      "default",
+			RequestsCPU:    resource.MustParse("1"),
+			RequestsMemory: resource.MustParse("1Gi"),
+		}
+
+		result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+		assert.False(t, result.IsSchedulable)
+		assert.Equal(t, "all 2 node(s) have taints the pod does not tolerate: dedicated=gpu:NoSchedule", result.Reason)
+		assert.Equal(t, "Add tolerations for dedicated=gpu:NoSchedule to the pod, or remove the taints from suitable nodes", result.Suggestion)
+		assert.Equal(t, []string{"dedicated=gpu:NoSchedule"}, result.UntoleratedTaints)
+		assert.Empty(t, result.Nodes)
+	})
+
+	t.Run("only the tainted node has room", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			{
+				Name:              "small-node",
+				AllocatableCPU:    resource.MustParse("2"),
+				AllocatableMemory: resource.MustParse("4Gi"),
+			},
+			{
+				Name:              "gpu-node",
+				AllocatableCPU:    resource.MustParse("8"),
+				AllocatableMemory: resource.MustParse("32Gi"),
+				Taints:            []corev1.Taint{gpuTaint},
+			},
+		}
+		pod := types.PodInfo{
+			Name:           "batch",
+			Namespace:      "default",
+			RequestsCPU:    resource.MustParse("4"),
+			RequestsMemory: resource.MustParse("1Gi"),
+		}
+
+		result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+		assert.False(t, result.IsSchedulable)
+		assert.Equal(t, "requests.cpu = 4 exceeds all node allocatable.cpu (max: 2); node(s) gpu-node have enough free resources but are excluded by untolerated taints", result.Reason)
+		assert.Equal(t, []string{"dedicated=gpu:NoSchedule"}, result.UntoleratedTaints)
+	})
+
+	t.Run("tolerated taint", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			{
+				Name:              "gpu-node",
+				AllocatableCPU:    resource.MustParse("8"),
+				AllocatableMemory: resource.MustParse("32Gi"),
+				Taints:            []corev1.Taint{gpuTaint},
+			},
+		}
+		pod := types.PodInfo{
+			Name:           "trainer",
+			Namespace:      "default",
+			RequestsCPU:    resource.MustParse("4"),
+			RequestsMemory: resource.MustParse("1Gi"),
+			Tolerations: []corev1.Toleration{
+				{Key: "dedicated", Operator: corev1.TolerationOpExists},
+			},
+		}
+
+		result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+		assert.True(t, result.IsSchedulable)
+		assert.Equal(t, []string{"gpu-node"}, result.FittingNodes)
+		assert.Empty(t, result.UntoleratedTaints)
+	})
+}
+
+func TestNodeSelectorMatches(t *testing.T) {
+	node := types.NodeInfo{
+		Name: "node-a",
+		Labels: map[string]string{
+			"pool":           "gpu",
+			"zone":           "zone-a",
+			"cpu-generation": "4",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		terms    []corev1.NodeSelectorTerm
+		expected bool
+	}{
+		{
+			name: "In matches",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu", "highmem"}},
+				}},
+			},
+			expected: true,
+		},
+		{
+			name: "In with missing label",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "team", Operator: corev1.NodeSelectorOpIn, Values: []string{"ml"}},
+				}},
+			},
+			expected: false,
+		},
+		{
+			name: "NotIn matches when value differs",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "zone", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"zone-b"}},
+				}},
+			},
+			expected: true,
+		},
+		{
+			name: "NotIn matches when label is missing",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "team", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"ml"}},
+				}},
+			},
+			expected: true,
+		},
+		{
+			name: "Exists and DoesNotExist",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "pool", Operator: corev1.NodeSelectorOpExists},
+					{Key: "spot", Operator: corev1.NodeSelectorOpDoesNotExist},
+				}},
+			},
+			expected: true,
+		},
+		{
+			name: "Gt and Lt",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "cpu-generation", Operator: corev1.NodeSelectorOpGt, Values: []string{"3"}},
+					{Key: "cpu-generation", Operator: corev1.NodeSelectorOpLt, Values: []string{"5"}},
+				}},
+			},
+			expected: true,
+		},
+		{
+			name: "Gt with non-integer value",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "zone", Operator: corev1.NodeSelectorOpGt, Values: []string{"1"}},
+				}},
+			},
+			expected: false,
+		},
+		{
+			name: "expressions within a term are ANDed",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu"}},
+					{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"zone-b"}},
+				}},
+			},
+			expected: false,
+		},
+		{
+			name: "terms are ORed",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"zone-b"}},
+				}},
+				{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"zone-a"}},
+				}},
+			},
+			expected: true,
+		},
+		{
+			name: "matchFields on metadata.name",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchFields: []corev1.NodeSelectorRequirement{
+					{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-a"}},
+				}},
+			},
+			expected: true,
+		},
+		{
+			name: "matchFields NotIn on metadata.name",
+			terms: []corev1.NodeSelectorTerm{
+				{MatchFields: []corev1.NodeSelectorRequirement{
+					{Key: "metadata.name", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"node-a"}},
+				}},
+			},
+			expected: false,
+		},
+		{
+			name:     "empty term matches nothing",
+			terms:    []corev1.NodeSelectorTerm{{}},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector := &corev1.NodeSelector{NodeSelectorTerms: tt.terms}
+			assert.Equal(t, tt.expected, nodeSelectorMatches(selector, node))
+		})
+	}
+}
+
+func TestAnalyzeSinglePod_NodeAffinity(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{
+			Name:              "general-node",
+			AllocatableCPU:    resource.MustParse("16"),
+			AllocatableMemory: resource.MustParse("64Gi"),
+			Labels:            map[string]string{"pool": "general"},
+		},
+		{
+			Name:              "batch-node",
+			AllocatableCPU:    resource.MustParse("4"),
+			AllocatableMemory: resource.MustParse("8Gi"),
+			Labels:            map[string]string{"pool": "batch"},
+		},
+	}
+
+	affinityFor := func(pool string) *corev1.NodeAffinity {
+		return &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{pool}},
+					}},
+				},
+			},
+		}
+	}
+
+	t.Run("evaluated against the pool capacity only", func(t *testing.T) {
+		pod := types.PodInfo{
+			Name:           "batch-job",
+			Namespace:      "default",
+			RequestsCPU:    resource.MustParse("8"),
+			RequestsMemory: resource.MustParse("4Gi"),
+			NodeAffinity:   affinityFor("batch"),
+		}
+
+		result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+		assert.False(t, result.IsSchedulable)
+		assert.Equal(t, "requests.cpu = 8 exceeds all node allocatable.cpu (max: 4); node(s) general-node have enough free resources but are excluded by node affinity", result.Reason)
+		assert.True(t, resource.MustParse("4").Equal(result.MaxAvailableCPU))
+		require.Len(t, result.Nodes, 1)
+		assert.Equal(t, "batch-node", result.Nodes[0].Name)
+	})
+
+	t.Run("no node matches affinity", func(t *testing.T) {
+		pod := types.PodInfo{
+			Name:           "gpu-job",
+			Namespace:      "default",
+			RequestsCPU:    resource.MustParse("1"),
+			RequestsMemory: resource.MustParse("1Gi"),
+			NodeAffinity:   affinityFor("gpu"),
+		}
+
+		result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+		assert.False(t, result.IsSchedulable)
+		assert.Equal(t, "no node matches affinity (2 node(s) checked against requiredDuringSchedulingIgnoredDuringExecution)", result.Reason)
+		assert.Equal(t, "Relax the pod's required node affinity or label nodes so that they match it", result.Suggestion)
+	})
+
+	t.Run("affinity and taints combined", func(t *testing.T) {
+		taintedNodes := append([]types.NodeInfo{}, nodes...)
+		taintedNodes[1].Taints = []corev1.Taint{{Key: "batch-only", Effect: corev1.TaintEffectNoSchedule}}
+		pod := types.PodInfo{
+			Name:           "batch-job",
+			Namespace:      "default",
+			RequestsCPU:    resource.MustParse("1"),
+			RequestsMemory: resource.MustParse("1Gi"),
+			NodeAffinity:   affinityFor("batch"),
+		}
+
+		result := (&Analyzer{}).analyzeSinglePod(pod, taintedNodes, false)
+
+		assert.False(t, result.IsSchedulable)
+		assert.Equal(t, "no node satisfies all scheduling constraints: 1 node(s) do not match the required node affinity; 1 node(s) have untolerated taints (batch-only:NoSchedule)", result.Reason)
+	})
+}
+
+func TestFilterByNodeSelector(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{Name: "gpu-a", Labels: map[string]string{"pool": "gpu", "zone": "a"}},
+		{Name: "gpu-b", Labels: map[string]string{"pool": "gpu", "zone": "b"}},
+		{Name: "general-a", Labels: map[string]string{"pool": "general", "zone": "a"}},
+	}
+
+	tests := []struct {
+		name              string
+		selector          map[string]string
+		expectedKept      []string
+		expectedUnmatched []string
+		expectedReason    string
+	}{
+		{
+			name:         "empty selector keeps every node",
+			selector:     nil,
+			expectedKept: []string{"gpu-a", "gpu-b", "general-a"},
+		},
+		{
+			name:         "single label",
+			selector:     map[string]string{"pool": "gpu"},
+			expectedKept: []string{"gpu-a", "gpu-b"},
+		},
+		{
+			name:              "label value present on no node",
+			selector:          map[string]string{"pool": "highmem", "zone": "a"},
+			expectedKept:      []string{},
+			expectedUnmatched: []string{"pool=highmem"},
+			expectedReason:    "no node matches nodeSelector: pool=highmem matched zero nodes",
+		},
+		{
+			name:           "labels present on different nodes",
+			selector:       map[string]string{"pool": "general", "zone": "b"},
+			expectedKept:   []string{},
+			expectedReason: "no node matches all nodeSelector labels (pool=general, zone=b)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, exclusion := filterByNodeSelector(types.PodInfo{Name: "pod", NodeSelector: tt.selector}, nodes, nodes)
+
+			assert.Equal(t, tt.expectedKept, nodeInfoNames(kept))
+			assert.Equal(t, tt.expectedUnmatched, exclusion.details)
+			if tt.expectedReason != "" {
+				assert.Equal(t, tt.expectedReason, exclusion.reason)
+			}
+		})
+	}
+}
+
+func TestFilterByNodeSelector_EntryOnlyOnExcludedNodes(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{Name: "general", Labels: map[string]string{"pool": "general"}},
+		{Name: "cordoned-gpu", Labels: map[string]string{"pool": "gpu"}, Unschedulable: true},
+	}
+	pod := types.PodInfo{Name: "pod", NodeSelector: map[string]string{"pool": "gpu"}}
+
+	candidates, _ := filterByNodeAvailability(pod, nodes)
+	kept, exclusion := filterByNodeSelector(pod, candidates, nodes)
+
+	assert.Empty(t, kept)
+	assert.Empty(t, exclusion.details)
+	assert.Equal(t, "no node matches all nodeSelector labels (pool=gpu)", exclusion.reason)
+
+	result := (&Analyzer{}).analyzeSinglePod(types.PodInfo{
+		Name:           "pod",
+		Namespace:      "default",
+		RequestsCPU:    resource.MustParse("100m"),
+		RequestsMemory: resource.MustParse("128Mi"),
+		NodeSelector:   pod.NodeSelector,
+	}, nodes, false)
+
+	assert.False(t, result.IsSchedulable)
+	assert.Empty(t, result.UnmatchedNodeSelector)
+}
+
+func TestAnalyzeSinglePod_NodeSelector(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{
+			Name:              "general-node",
+			AllocatableCPU:    resource.MustParse("4"),
+			AllocatableMemory: resource.MustParse("8Gi"),
+			Labels:            map[string]string{"pool": "general"},
+		},
+	}
+	pod := types.PodInfo{
+		Name:           "gpu-job",
+		Namespace:      "default",
+		RequestsCPU:    resource.MustParse("1"),
+		RequestsMemory: resource.MustParse("1Gi"),
+		NodeSelector:   map[string]string{"pool": "gpu"},
+	}
+
+	result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+	assert.False(t, result.IsSchedulable)
+	assert.Equal(t, "no node matches nodeSelector: pool=gpu matched zero nodes", result.Reason)
+	assert.Equal(t, "Label nodes with pool=gpu or remove those entries from the pod's nodeSelector", result.Suggestion)
+	assert.Equal(t, []string{"pool=gpu"}, result.UnmatchedNodeSelector)
+}
+
+func TestFilterByPodCapacity(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{Name: "full-node", AllocatablePods: 110, PodCount: 110},
+		{Name: "open-node", AllocatablePods: 110, PodCount: 20},
+		{Name: "unlimited-node", PodCount: 500},
+	}
+
+	kept, exclusion := filterByPodCapacity(nodes)
+
+	assert.Equal(t, []string{"open-node", "unlimited-node"}, nodeInfoNames(kept))
+	assert.Equal(t, []string{"full-node"}, exclusion.details)
+	assert.Equal(t, "pod limit", exclusion.constraint)
+}
+
+func TestAnalyzeSinglePod_PodLimit(t *testing.T) {
+	pod := types.PodInfo{
+		Name:           "web",
+		Namespace:      "default",
+		RequestsCPU:    resource.MustParse("100m"),
+		RequestsMemory: resource.MustParse("128Mi"),
+	}
+
+	t.Run("every node full", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			{
+				Name:              "node1",
+				AllocatableCPU:    resource.MustParse("8"),
+				AllocatableMemory: resource.MustParse("32Gi"),
+				AllocatablePods:   110,
+				PodCount:          110,
+			},
+			{
+				Name:              "node2",
+				AllocatableCPU:    resource.MustParse("8"),
+				AllocatableMemory: resource.MustParse("32Gi"),
+				AllocatablePods:   110,
+				PodCount:          110,
+			},
+		}
+
+		result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+		assert.False(t, result.IsSchedulable)
+		assert.Equal(t, "Too many pods: all 2 node(s) have reached their allocatable pod limit (node1, node2)", result.Reason)
+		assert.Equal(t, "Scale down other workloads, raise the kubelet maxPods setting, or add nodes", result.Suggestion)
+		assert.Equal(t, []string{"node1", "node2"}, result.FullNodes)
+	})
+
+	t.Run("full node excluded while others lack cpu", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			{
+				Name:              "full-node",
+				AllocatableCPU:    resource.MustParse("8"),
+				AllocatableMemory: resource.MustParse("32Gi"),
+				AllocatablePods:   110,
+				PodCount:          110,
+			},
+			{
+				Name:              "busy-node",
+				AllocatableCPU:    resource.MustParse("8"),
+				AllocatableMemory: resource.MustParse("32Gi"),
+				RequestedCPU:      resource.MustParse("8"),
+				AllocatablePods:   110,
+				PodCount:          40,
+			},
+		}
+
+		result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+		assert.False(t, result.IsSchedulable)
+		assert.Equal(t, "requests.cpu = 100m exceeds the free cpu left by running pods on all nodes (max free: 0); "+
+			"node(s) full-node have enough free resources but are excluded by pod limit", result.Reason)
+		assert.Equal(t, []string{"full-node"}, result.FullNodes)
+	})
+}
+
+func TestFilterByNodeAvailability(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{Name: "ready-node", Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+		{Name: "cordoned-node", Unschedulable: true},
+		{Name: "not-ready-node", Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}}},
+		{Name: "unknown-node", Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}}},
+		{Name: "no-conditions-node"},
+	}
+
+	t.Run("pod without tolerations", func(t *testing.T) {
+		kept, exclusion := filterByNodeAvailability(types.PodInfo{Name: "pod"}, nodes)
+
+		assert.Equal(t, []string{"ready-node", "no-conditions-node"}, nodeInfoNames(kept))
+		assert.Equal(t, []string{"cordoned-node (cordoned)", "not-ready-node (NotReady)", "unknown-node (Ready=Unknown)"},
+			exclusion.details)
+	})
+
+	t.Run("pod tolerating unschedulable nodes", func(t *testing.T) {
+		pod := types.PodInfo{
+			Name: "pod",
+			Tolerations: []corev1.Toleration{
+				{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+		}
+
+		kept, _ := filterByNodeAvailability(pod, nodes)
+
+		assert.Equal(t, []string{"ready-node", "cordoned-node", "no-conditions-node"}, nodeInfoNames(kept))
+	})
+
+	t.Run("pod tolerating not-ready nodes", func(t *testing.T) {
+		pod := types.PodInfo{
+			Name: "pod",
+			Tolerations: []corev1.Toleration{
+				{Key: corev1.TaintNodeNotReady, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+		}
+
+		kept, exclusion := filterByNodeAvailability(pod, nodes)
+
+		assert.Equal(t, []string{"ready-node", "not-ready-node", "no-conditions-node"}, nodeInfoNames(kept))
+		assert.Equal(t, []string{"node(s) had untolerated taint {node.kubernetes.io/unreachable: }"},
+			exclusion.predicates["unknown-node"])
+	})
+
+	t.Run("pod tolerating unreachable nodes", func(t *testing.T) {
+		pod := types.PodInfo{
+			Name: "pod",
+			Tolerations: []corev1.Toleration{
+				{Key: corev1.TaintNodeUnreachable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+		}
+
+		kept, exclusion := filterByNodeAvailability(pod, nodes)
+
+		assert.Equal(t, []string{"ready-node", "unknown-node", "no-conditions-node"}, nodeInfoNames(kept))
+		assert.Equal(t, []string{"node(s) had untolerated taint {node.kubernetes.io/not-ready: }"},
+			exclusion.predicates["not-ready-node"])
+	})
+}
+
+func TestAnalyzeSinglePod_UnavailableNodes(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{
+			Name:              "large-cordoned-node",
+			AllocatableCPU:    resource.MustParse("16"),
+			AllocatableMemory: resource.MustParse("64Gi"),
+			Unschedulable:     true,
+		},
+		{
+			Name:              "small-node",
+			AllocatableCPU:    resource.MustParse("2"),
+			AllocatableMemory: resource.MustParse("4Gi"),
+		},
+	}
+	pod := types.PodInfo{
+		Name:           "big-pod",
+		Namespace:      "default",
+		RequestsCPU:    resource.MustParse("8"),
+		RequestsMemory: resource.MustParse("16Gi"),
+	}
+
+	t.Run("only fitting node is cordoned", func(t *testing.T) {
+		result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+		assert.False(t, result.IsSchedulable)
+		assert.Equal(t, "requests.cpu = 8 and requests.memory = 16Gi exceed all node allocatable resources (max CPU: 2, max memory: 4Gi); "+
+			"node(s) large-cordoned-node have enough free resources but are excluded by cordoned or NotReady state", result.Reason)
+		assert.Equal(t, []string{"large-cordoned-node (cordoned)"}, result.UnavailableNodes)
+	})
+
+	t.Run("every node unavailable", func(t *testing.T) {
+		result := (&Analyzer{}).analyzeSinglePod(pod, nodes[:1], false)
+
+		assert.False(t, result.IsSchedulable)
+		assert.Equal(t, "all 1 node(s) are cordoned or not ready: large-cordoned-node (cordoned)", result.Reason)
+	})
+
+	t.Run("unavailable nodes included", func(t *testing.T) {
+		analyzer := &Analyzer{}
+		analyzer.SetIncludeUnavailableNodes(true)
+
+		result := analyzer.analyzeSinglePod(pod, nodes, false)
+
+		assert.True(t, result.IsSchedulable)
+		assert.Equal(t, []string{"large-cordoned-node"}, result.FittingNodes)
+	})
+
+	t.Run("unavailable nodes with their taints included", func(t *testing.T) {
+		tainted := func(name string, status corev1.ConditionStatus, taints ...corev1.Taint) types.NodeInfo {
+			return types.NodeInfo{
+				Name:              name,
+				AllocatableCPU:    resource.MustParse("16"),
+				AllocatableMemory: resource.MustParse("64Gi"),
+				Conditions:        []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+				Taints:            taints,
+			}
+		}
+		taintedNodes := []types.NodeInfo{
+			tainted("cordoned", corev1.ConditionTrue,
+				corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}),
+			tainted("not-ready", corev1.ConditionFalse,
+				corev1.Taint{Key: corev1.TaintNodeNotReady, Effect: corev1.TaintEffectNoSchedule},
+				corev1.Taint{Key: corev1.TaintNodeNotReady, Effect: corev1.TaintEffectNoExecute}),
+			tainted("unreachable", corev1.ConditionUnknown,
+				corev1.Taint{Key: corev1.TaintNodeUnreachable, Effect: corev1.TaintEffectNoSchedule},
+				corev1.Taint{Key: corev1.TaintNodeUnreachable, Effect: corev1.TaintEffectNoExecute}),
+			tainted("dedicated", corev1.ConditionTrue,
+				corev1.Taint{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}),
+		}
+		taintedNodes[0].Unschedulable = true
+
+		analyzer := &Analyzer{}
+		analyzer.SetIncludeUnavailableNodes(true)
+
+		result := analyzer.analyzeSinglePod(pod, taintedNodes, false)
+
+		assert.True(t, result.IsSchedulable)
+		assert.Equal(t, []string{"cordoned", "not-ready", "unreachable"}, result.FittingNodes)
+		assert.Empty(t, pod.Tolerations)
+	})
+}
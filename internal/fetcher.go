@@ -3,10 +3,16 @@ package internal
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -97,20 +103,29 @@ func (f *Fetcher) FetchNodes(ctx context.Context) ([]types.NodeInfo, error) {
 
 	logrus.WithField("nodes_count", len(nodes.Items)).Info("Successfully fetched cluster nodes")
 
+	volumeLimits := f.fetchVolumeLimits(ctx)
+
 	nodeInfos := make([]types.NodeInfo, 0, len(nodes.Items))
 	for _, node := range nodes.Items {
 		nodeInfo := types.NodeInfo{
 			Name:              node.Name,
 			AllocatableCPU:    node.Status.Allocatable.Cpu().DeepCopy(),
 			AllocatableMemory: node.Status.Allocatable.Memory().DeepCopy(),
+			Allocatable:       node.Status.Allocatable.DeepCopy(),
+			AllocatablePods:   node.Status.Allocatable.Pods().Value(),
+			Unschedulable:     node.Spec.Unschedulable,
+			Conditions:        node.Status.Conditions,
 			Taints:            node.Spec.Taints,
 			Labels:            node.Labels,
+			VolumeLimits:      volumeLimits[node.Name],
 		}
 
 		logrus.WithFields(logrus.Fields{
 			"node_name":          node.Name,
 			"allocatable_cpu":    nodeInfo.AllocatableCPU.String(),
 			"allocatable_memory": nodeInfo.AllocatableMemory.String(),
+			"allocatable_pods":   nodeInfo.AllocatablePods,
+			"unschedulable":      nodeInfo.Unschedulable,
 			"taints_count":       len(node.Spec.Taints),
 		}).Debug("Processed node information")
 
@@ -159,9 +174,30 @@ func (f *Fetcher) FetchPendingPods(ctx context.Context, namespace string) ([]typ
 		"pending_pods_count": len(pods.Items),
 	}).Info("Successfully fetched pending pods")
 
+	events := f.fetchFailedSchedulingEvents(ctx, namespace)
+	runtimeClasses := make(map[string]*nodev1.RuntimeClass)
+	priorityClasses := make(map[string]*schedulingv1.PriorityClass)
+	volumes := newVolumeCache(namespace)
 	podInfos := make([]types.PodInfo, 0, len(pods.Items))
 	for _, pod := range pods.Items {
+		// Guard against API servers that ignore the field selector.
+		if pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+
 		podInfo := f.parsePodResources(pod)
+		if podInfo.RuntimeClassName != "" {
+			if runtimeClass := f.fetchRuntimeClass(ctx, podInfo.RuntimeClassName, runtimeClasses); runtimeClass != nil {
+				podInfo = applyRuntimeClass(podInfo, runtimeClass)
+			}
+		}
+		if pod.Spec.Priority == nil && podInfo.PriorityClassName != "" {
+			if priorityClass := f.fetchPriorityClass(ctx, podInfo.PriorityClassName, priorityClasses); priorityClass != nil {
+				podInfo = applyPriorityClass(podInfo, priorityClass)
+			}
+		}
+		podInfo.VolumeClaims = f.resolveVolumeClaims(ctx, pod, volumes)
+		podInfo.SchedulerReports = schedulerReports(pod, events[pod.Namespace+"/"+pod.Name])
 
 		logrus.WithFields(logrus.Fields{
 			"pod_name":        pod.Name,
@@ -176,52 +212,819 @@ func (f *Fetcher) FetchPendingPods(ctx context.Context, namespace string) ([]typ
 	return podInfos, nil
 }
 
-// parsePodResources extracts and aggregates resource information from a pod specification.
-// It calculates total CPU and memory requests/limits across all containers in the pod,
-// and extracts scheduling constraints like node affinity and tolerations.
+// FetchScheduledPods retrieves all non-terminal pods that are already bound to a node.
+// The resource requests of these pods are what the scheduler subtracts from each node's
+// allocatable capacity, so they are needed to determine how much room is actually free.
 //
 // Parameters:
-//   - pod: The Kubernetes pod object to parse
+//   - ctx: Context for the API request, used for cancellation and timeout
 //
 // Returns:
-//   - types.PodInfo: Structured pod information including aggregated resources and scheduling constraints
-func (f *Fetcher) parsePodResources(pod corev1.Pod) types.PodInfo {
-	var totalRequestsCPU, totalRequestsMemory resource.Quantity
-	var totalLimitsCPU, totalLimitsMemory resource.Quantity
+//   - []types.PodInfo: A slice of PodInfo for bound pods, with NodeName set to the hosting node
+//   - error: An error if the pod listing operation fails
+func (f *Fetcher) FetchScheduledPods(ctx context.Context) ([]types.PodInfo, error) {
+	listOptions := metav1.ListOptions{
+		FieldSelector: "spec.nodeName!=,status.phase!=Succeeded,status.phase!=Failed",
+	}
+
+	logrus.Debug("Fetching pods bound to nodes cluster-wide")
+
+	pods, err := f.clientset.CoreV1().Pods("").List(ctx, listOptions)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list scheduled pods from Kubernetes API")
+		return nil, fmt.Errorf("failed to list scheduled pods: %w", err)
+	}
+
+	volumes := newVolumeCache("")
+	podInfos := make([]types.PodInfo, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		if !isBoundNonTerminalPod(pod) {
+			continue
+		}
+
+		podInfo := f.parsePodResources(pod)
+		podInfo.VolumeClaims = f.resolveVolumeClaims(ctx, pod, volumes)
+		podInfos = append(podInfos, podInfo)
+	}
+
+	logrus.WithField("scheduled_pods_count", len(podInfos)).Info("Successfully fetched scheduled pods")
+
+	return podInfos, nil
+}
+
+// FetchNamespaceLabels retrieves the labels of every namespace. They are needed to
+// resolve the namespaceSelector of inter-pod affinity terms.
+//
+// Parameters:
+//   - ctx: Context for the API request, used for cancellation and timeout
+//
+// Returns:
+//   - map[string]map[string]string: Namespace labels keyed by namespace name
+//   - error: An error if the namespace listing operation fails
+func (f *Fetcher) FetchNamespaceLabels(ctx context.Context) (map[string]map[string]string, error) {
+	logrus.Debug("Fetching namespace labels")
+
+	namespaces, err := f.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list namespaces from Kubernetes API")
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	namespaceLabels := make(map[string]map[string]string, len(namespaces.Items))
+	for _, namespace := range namespaces.Items {
+		namespaceLabels[namespace.Name] = namespace.Labels
+	}
+
+	logrus.WithField("namespaces_count", len(namespaceLabels)).Debug("Successfully fetched namespace labels")
+
+	return namespaceLabels, nil
+}
+
+// fetchFailedSchedulingEvents lists the FailedScheduling events of pods in the namespace, or
+// cluster-wide when it is empty, and keeps the latest one per pod. A failed listing is logged
+// and yields no events, so that missing RBAC permissions do not abort the analysis.
+//
+// Parameters:
+//   - ctx: Context for the API request
+//   - namespace: Namespace to list events in. If empty, lists cluster-wide
+//
+// Returns:
+//   - map[string]*corev1.Event: The latest event keyed by "<namespace>/<pod name>"
+func (f *Fetcher) fetchFailedSchedulingEvents(ctx context.Context, namespace string) map[string]*corev1.Event {
+	events, err := f.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=Pod,reason=FailedScheduling",
+	})
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list FailedScheduling events; scheduler messages are not reported")
+		return nil
+	}
+
+	latest := make(map[string]*corev1.Event)
+	for i := range events.Items {
+		event := &events.Items[i]
+		// Guard against API servers that ignore the field selector.
+		if event.InvolvedObject.Kind != "Pod" || event.Reason != "FailedScheduling" {
+			continue
+		}
+
+		key := event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name
+		if current, ok := latest[key]; !ok || eventTime(*event).After(eventTime(*current)) {
+			latest[key] = event
+		}
+	}
+
+	return latest
+}
+
+// FetchPodDisruptionBudgets retrieves every PodDisruptionBudget in the cluster. They
+// decide which running pods may be evicted when simulating preemption.
+//
+// Parameters:
+//   - ctx: Context for the API request, used for cancellation and timeout
+//
+// Returns:
+//   - []types.PodDisruptionBudgetInfo: The budgets with their selectors and allowed disruptions
+//   - error: An error if the PodDisruptionBudget listing operation fails
+func (f *Fetcher) FetchPodDisruptionBudgets(ctx context.Context) ([]types.PodDisruptionBudgetInfo, error) {
+	logrus.Debug("Fetching PodDisruptionBudgets cluster-wide")
+
+	budgets, err := f.clientset.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list PodDisruptionBudgets from Kubernetes API")
+		return nil, fmt.Errorf("failed to list pod disruption budgets: %w", err)
+	}
+
+	budgetInfos := make([]types.PodDisruptionBudgetInfo, 0, len(budgets.Items))
+	for _, budget := range budgets.Items {
+		budgetInfos = append(budgetInfos, types.PodDisruptionBudgetInfo{
+			Name:               budget.Name,
+			Namespace:          budget.Namespace,
+			Selector:           budget.Spec.Selector,
+			DisruptionsAllowed: budget.Status.DisruptionsAllowed,
+		})
+	}
+
+	logrus.WithField("pdbs_count", len(budgetInfos)).Debug("Successfully fetched PodDisruptionBudgets")
+
+	return budgetInfos, nil
+}
+
+// FetchSchedulerLeases retrieves the names of the leader election Leases in kube-system
+// that are currently held. A scheduler holds a Lease named after itself, so a held Lease
+// shows that the scheduler runs even when it has not placed any pod yet.
+//
+// Parameters:
+//   - ctx: Context for the API request, used for cancellation and timeout
+//
+// Returns:
+//   - []string: The names of the held Leases
+//   - error: An error if the Lease listing operation fails
+func (f *Fetcher) FetchSchedulerLeases(ctx context.Context) ([]string, error) {
+	logrus.Debug("Fetching leader election Leases in kube-system")
+
+	leases, err := f.clientset.CoordinationV1().Leases(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list Leases from Kubernetes API")
+		return nil, fmt.Errorf("failed to list leases: %w", err)
+	}
+
+	now := time.Now()
+	names := make([]string, 0, len(leases.Items))
+	for _, lease := range leases.Items {
+		if leaseHeld(lease, now) {
+			names = append(names, lease.Name)
+		}
+	}
+
+	logrus.WithField("leases_count", len(names)).Debug("Successfully fetched held Leases")
+
+	return names, nil
+}
+
+// leaseHeld reports whether the Lease has a holder whose last renewal has not expired.
+// A Lease without a renewal time or duration counts as held as long as it has a holder.
+func leaseHeld(lease coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return false
+	}
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	duration := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	return lease.Spec.RenewTime.Add(duration).After(now)
+}
+
+// fetchVolumeLimits reads the per-driver volume attach limits that CSI drivers publish in
+// CSINode objects. Drivers without an allocatable count are not limited. A failed listing is
+// logged and yields no limits, so that missing RBAC permissions do not abort the analysis.
+//
+// Parameters:
+//   - ctx: Context for the API request
+//
+// Returns:
+//   - map[string]map[string]int64: Attach limits keyed by node name and then by driver name
+func (f *Fetcher) fetchVolumeLimits(ctx context.Context) map[string]map[string]int64 {
+	csiNodes, err := f.clientset.StorageV1().CSINodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list CSINodes; volume attach limits are ignored")
+		return nil
+	}
 
-	for _, container := range pod.Spec.Containers {
-		if container.Resources.Requests != nil {
-			if cpu := container.Resources.Requests.Cpu(); cpu != nil {
-				totalRequestsCPU.Add(*cpu)
+	limits := make(map[string]map[string]int64, len(csiNodes.Items))
+	for _, csiNode := range csiNodes.Items {
+		for _, driver := range csiNode.Spec.Drivers {
+			if driver.Allocatable == nil || driver.Allocatable.Count == nil {
+				continue
 			}
-			if memory := container.Resources.Requests.Memory(); memory != nil {
-				totalRequestsMemory.Add(*memory)
+			if limits[csiNode.Name] == nil {
+				limits[csiNode.Name] = make(map[string]int64)
 			}
+			limits[csiNode.Name][driver.Name] = int64(*driver.Allocatable.Count)
+		}
+	}
+
+	return limits
+}
+
+// volumeCache holds the PersistentVolumeClaims, PersistentVolumes and StorageClasses read
+// during one fetch. Claims and volumes are listed once, the first time a pod with a claim is
+// resolved, so that pods without claims cause no requests and the number of requests does
+// not grow with the number of claims.
+type volumeCache struct {
+	namespace         string
+	claims            map[string]*corev1.PersistentVolumeClaim
+	claimsListed      bool
+	claimsErr         error
+	persistentVolumes map[string]*corev1.PersistentVolume
+	volumesListed     bool
+	storageClasses    map[string]*storagev1.StorageClass
+}
+
+// newVolumeCache creates a cache that lists the claims of the namespace, or of all
+// namespaces if it is empty.
+func newVolumeCache(namespace string) *volumeCache {
+	return &volumeCache{
+		namespace:      namespace,
+		storageClasses: make(map[string]*storagev1.StorageClass),
+	}
+}
+
+// resolveVolumeClaims looks up every PersistentVolumeClaim used by the pod, including the
+// claims created for generic ephemeral volumes, together with the node affinity and CSI driver
+// of bound volumes and the binding mode, allowed topologies and provisioner of the StorageClass
+// of unbound claims.
+// Claims that do not exist are reported with phase "Missing". If the claims cannot be listed,
+// the failure is logged and the claims are skipped, so that missing RBAC permissions do not
+// abort the analysis.
+//
+// Parameters:
+//   - ctx: Context for the API requests
+//   - pod: The pod whose volumes are resolved
+//   - cache: Claims, PersistentVolumes and StorageClasses already read during this fetch
+//
+// Returns:
+//   - []types.VolumeClaimInfo: One entry per claim, in volume order
+func (f *Fetcher) resolveVolumeClaims(ctx context.Context, pod corev1.Pod, cache *volumeCache) []types.VolumeClaimInfo {
+	var claims []types.VolumeClaimInfo
+
+	for _, volume := range pod.Spec.Volumes {
+		var claimName string
+		switch {
+		case volume.PersistentVolumeClaim != nil:
+			claimName = volume.PersistentVolumeClaim.ClaimName
+		case volume.Ephemeral != nil:
+			claimName = pod.Name + "-" + volume.Name
+		default:
+			continue
 		}
 
-		if container.Resources.Limits != nil {
-			if cpu := container.Resources.Limits.Cpu(); cpu != nil {
-				totalLimitsCPU.Add(*cpu)
+		if err := f.listVolumeClaims(ctx, cache); err != nil {
+			return nil
+		}
+		claim := cache.claims[pod.Namespace+"/"+claimName]
+		if claim == nil {
+			claims = append(claims, types.VolumeClaimInfo{Name: claimName, Phase: volumeClaimMissing})
+			continue
+		}
+
+		info := types.VolumeClaimInfo{
+			Name:       claimName,
+			Phase:      string(claim.Status.Phase),
+			VolumeName: claim.Spec.VolumeName,
+		}
+		if claim.Spec.StorageClassName != nil {
+			info.StorageClassName = *claim.Spec.StorageClassName
+		}
+
+		if info.VolumeName != "" {
+			if volume := f.fetchPersistentVolume(ctx, info.VolumeName, cache); volume != nil {
+				if volume.Spec.NodeAffinity != nil {
+					info.NodeAffinity = volume.Spec.NodeAffinity.Required
+				}
+				if volume.Spec.CSI != nil {
+					info.Driver = volume.Spec.CSI.Driver
+				}
 			}
-			if memory := container.Resources.Limits.Memory(); memory != nil {
-				totalLimitsMemory.Add(*memory)
+		} else if info.StorageClassName != "" {
+			if storageClass := f.fetchStorageClass(ctx, info.StorageClassName, cache); storageClass != nil {
+				// The API server defaults volumeBindingMode to Immediate; an empty mode marks
+				// a StorageClass that could not be resolved.
+				info.VolumeBindingMode = string(storagev1.VolumeBindingImmediate)
+				if storageClass.VolumeBindingMode != nil {
+					info.VolumeBindingMode = string(*storageClass.VolumeBindingMode)
+				}
+				info.AllowedTopologies = storageClass.AllowedTopologies
+				info.Driver = storageClass.Provisioner
 			}
 		}
+
+		claims = append(claims, info)
+	}
+
+	return claims
+}
+
+// listVolumeClaims lists the PersistentVolumeClaims of the cache's namespace into the cache,
+// keyed by namespace and name, unless they were already listed. A failure is logged once and
+// returned on every later call.
+func (f *Fetcher) listVolumeClaims(ctx context.Context, cache *volumeCache) error {
+	if cache.claimsListed {
+		return cache.claimsErr
+	}
+	cache.claimsListed = true
+
+	list, err := f.clientset.CoreV1().PersistentVolumeClaims(cache.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"namespace": cache.namespace,
+			"error":     err.Error(),
+		}).Warn("Failed to list PersistentVolumeClaims; volume topology and attach limits are ignored")
+		cache.claimsErr = fmt.Errorf("failed to list persistent volume claims: %w", err)
+		return cache.claimsErr
+	}
+
+	cache.claims = make(map[string]*corev1.PersistentVolumeClaim, len(list.Items))
+	for i := range list.Items {
+		claim := &list.Items[i]
+		cache.claims[claim.Namespace+"/"+claim.Name] = claim
+	}
+	return nil
+}
+
+// fetchPersistentVolume looks up a PersistentVolume by name, listing all PersistentVolumes
+// into the cache the first time. A failed listing is logged and yields nil for every volume.
+func (f *Fetcher) fetchPersistentVolume(ctx context.Context, name string, cache *volumeCache) *corev1.PersistentVolume {
+	if !cache.volumesListed {
+		cache.volumesListed = true
+		list, err := f.clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to list PersistentVolumes; their node affinity is ignored")
+		} else {
+			cache.persistentVolumes = make(map[string]*corev1.PersistentVolume, len(list.Items))
+			for i := range list.Items {
+				cache.persistentVolumes[list.Items[i].Name] = &list.Items[i]
+			}
+		}
+	}
+
+	return cache.persistentVolumes[name]
+}
+
+// fetchStorageClass looks up a StorageClass by name through the cache. Failures are logged
+// and yield nil.
+func (f *Fetcher) fetchStorageClass(ctx context.Context, name string, cache *volumeCache) *storagev1.StorageClass {
+	if storageClass, ok := cache.storageClasses[name]; ok {
+		return storageClass
+	}
+
+	storageClass, err := f.clientset.StorageV1().StorageClasses().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"storage_class": name,
+			"error":         err.Error(),
+		}).Warn("Failed to fetch StorageClass; its binding mode and topology are ignored")
+		storageClass = nil
+	}
+
+	cache.storageClasses[name] = storageClass
+	return storageClass
+}
+
+// isBoundNonTerminalPod reports whether the pod is assigned to a node and still
+// occupies resources there. The check mirrors the field selector used when listing,
+// so results stay correct even when the API server ignores the selector.
+func isBoundNonTerminalPod(pod corev1.Pod) bool {
+	if pod.Spec.NodeName == "" {
+		return false
+	}
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// parsePodResources extracts and aggregates resource information from a pod specification.
+// It calculates the effective CPU and memory requests/limits the scheduler uses for the pod,
+// taking init and sidecar containers into account, and extracts scheduling constraints like
+// node affinity and tolerations.
+//
+// Parameters:
+//   - pod: The Kubernetes pod object to parse
+//
+// Returns:
+//   - types.PodInfo: Structured pod information including aggregated resources and scheduling constraints
+func (f *Fetcher) parsePodResources(pod corev1.Pod) types.PodInfo {
+	requests := make(corev1.ResourceList)
+	limits := make(corev1.ResourceList)
+	var requestsCPUSource, requestsMemorySource string
+
+	for _, name := range podResourceNames(pod.Spec) {
+		request, source := effectivePodResource(pod.Spec, name, containerRequests)
+		if !request.IsZero() {
+			requests[name] = request
+		}
+		switch name {
+		case corev1.ResourceCPU:
+			requestsCPUSource = source
+		case corev1.ResourceMemory:
+			requestsMemorySource = source
+		}
+
+		if limit, _ := effectivePodResource(pod.Spec, name, containerLimits); !limit.IsZero() {
+			limits[name] = limit
+		}
 	}
 
 	var nodeAffinity *corev1.NodeAffinity
+	var podAffinity *corev1.PodAffinity
+	var podAntiAffinity *corev1.PodAntiAffinity
 	if pod.Spec.Affinity != nil {
 		nodeAffinity = pod.Spec.Affinity.NodeAffinity
+		podAffinity = pod.Spec.Affinity.PodAffinity
+		podAntiAffinity = pod.Spec.Affinity.PodAntiAffinity
+	}
+
+	var runtimeClassName string
+	if pod.Spec.RuntimeClassName != nil {
+		runtimeClassName = *pod.Spec.RuntimeClassName
+	}
+
+	var priority int32
+	if pod.Spec.Priority != nil {
+		priority = *pod.Spec.Priority
+	}
+	var preemptionPolicy string
+	if pod.Spec.PreemptionPolicy != nil {
+		preemptionPolicy = string(*pod.Spec.PreemptionPolicy)
+	}
+
+	podInfo := types.PodInfo{
+		Name:                      pod.Name,
+		Namespace:                 pod.Namespace,
+		NodeName:                  pod.Spec.NodeName,
+		RequestsCPU:               requests.Cpu().DeepCopy(),
+		RequestsMemory:            requests.Memory().DeepCopy(),
+		RequestsCPUSource:         requestsCPUSource,
+		RequestsMemorySource:      requestsMemorySource,
+		LimitsCPU:                 limits.Cpu().DeepCopy(),
+		LimitsMemory:              limits.Memory().DeepCopy(),
+		Requests:                  requests,
+		Limits:                    limits,
+		RuntimeClassName:          runtimeClassName,
+		NodeSelector:              pod.Spec.NodeSelector,
+		NodeAffinity:              nodeAffinity,
+		Labels:                    pod.Labels,
+		PodAffinity:               podAffinity,
+		PodAntiAffinity:           podAntiAffinity,
+		Tolerations:               pod.Spec.Tolerations,
+		TopologySpreadConstraints: pod.Spec.TopologySpreadConstraints,
+		HostPorts:                 podHostPorts(pod.Spec),
+		PriorityClassName:         pod.Spec.PriorityClassName,
+		Priority:                  priority,
+		PreemptionPolicy:          preemptionPolicy,
+		WaitingContainers:         waitingContainers(pod.Status),
+		SchedulerName:             pod.Spec.SchedulerName,
+		SchedulingGates:           schedulingGateNames(pod.Spec),
+		NominatedNodeName:         pod.Status.NominatedNodeName,
+		Terminating:               pod.DeletionTimestamp != nil,
+	}
+	if emptyDirMemory := memoryBackedEmptyDirLimit(pod.Spec); !emptyDirMemory.IsZero() {
+		podInfo.MemoryBackedEmptyDir = &emptyDirMemory
+	}
+
+	return addPodOverhead(podInfo, pod.Spec.Overhead)
+}
+
+// schedulingGateNames returns the names of the pod's scheduling gates.
+func schedulingGateNames(spec corev1.PodSpec) []string {
+	var names []string
+	for _, gate := range spec.SchedulingGates {
+		names = append(names, gate.Name)
+	}
+	return names
+}
+
+// waitingContainers lists the init and app containers that are waiting to start, with the
+// reason the kubelet reports, e.g. ImagePullBackOff or CreateContainerConfigError.
+//
+// Parameters:
+//   - status: The pod status
+//
+// Returns:
+//   - []types.ContainerWaiting: The waiting containers, init containers first
+func waitingContainers(status corev1.PodStatus) []types.ContainerWaiting {
+	var waiting []types.ContainerWaiting
+	for _, statuses := range [][]corev1.ContainerStatus{status.InitContainerStatuses, status.ContainerStatuses} {
+		for _, containerStatus := range statuses {
+			if containerStatus.State.Waiting == nil {
+				continue
+			}
+			waiting = append(waiting, types.ContainerWaiting{
+				Container: containerStatus.Name,
+				Reason:    containerStatus.State.Waiting.Reason,
+				Message:   containerStatus.State.Waiting.Message,
+			})
+		}
+	}
+	return waiting
+}
+
+// podHostPorts collects the container ports that bind a hostPort. App containers and
+// restartable init containers (sidecars) are included, as they keep running alongside each
+// other; regular init containers have exited before the app containers start.
+//
+// Parameters:
+//   - spec: The pod specification
+//
+// Returns:
+//   - []corev1.ContainerPort: The ports with a non-zero hostPort, in container order
+func podHostPorts(spec corev1.PodSpec) []corev1.ContainerPort {
+	var ports []corev1.ContainerPort
+	collect := func(container corev1.Container) {
+		for _, port := range container.Ports {
+			if port.HostPort > 0 {
+				ports = append(ports, port)
+			}
+		}
+	}
+
+	for _, container := range spec.InitContainers {
+		if container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			collect(container)
+		}
+	}
+	for _, container := range spec.Containers {
+		collect(container)
+	}
+
+	return ports
+}
+
+// addPodOverhead adds the RuntimeClass pod overhead to the effective requests, as the
+// scheduler does. Limits are only raised when the pod already sets them, because a
+// missing limit means unbounded and must stay that way.
+//
+// Parameters:
+//   - podInfo: The parsed pod information
+//   - overhead: The pod's spec.overhead, which may be empty
+//
+// Returns:
+//   - types.PodInfo: The pod information with Overhead recorded and requests adjusted
+func addPodOverhead(podInfo types.PodInfo, overhead corev1.ResourceList) types.PodInfo {
+	if len(overhead) == 0 {
+		return podInfo
+	}
+
+	podInfo.Overhead = overhead.DeepCopy()
+	if podInfo.Requests == nil {
+		podInfo.Requests = make(corev1.ResourceList)
+	}
+	for name, quantity := range overhead {
+		request := podInfo.Requests[name]
+		request.Add(quantity)
+		podInfo.Requests[name] = request
+
+		if limit, ok := podInfo.Limits[name]; ok {
+			limit.Add(quantity)
+			podInfo.Limits[name] = limit
+		}
+	}
+
+	podInfo.RequestsCPU = podInfo.Requests.Cpu().DeepCopy()
+	podInfo.RequestsMemory = podInfo.Requests.Memory().DeepCopy()
+	podInfo.LimitsCPU = podInfo.Limits.Cpu().DeepCopy()
+	podInfo.LimitsMemory = podInfo.Limits.Memory().DeepCopy()
+
+	return podInfo
+}
+
+// fetchRuntimeClass looks up a RuntimeClass by name, caching the result for the duration
+// of one fetch. Lookup failures are logged and yield nil so that a missing RuntimeClass or
+// missing RBAC permission does not abort the whole analysis.
+//
+// Parameters:
+//   - ctx: Context for the API request
+//   - name: The RuntimeClass name referenced by the pod
+//   - cache: RuntimeClasses already resolved during this fetch, keyed by name
+//
+// Returns:
+//   - *nodev1.RuntimeClass: The RuntimeClass, or nil if it could not be fetched
+func (f *Fetcher) fetchRuntimeClass(ctx context.Context, name string, cache map[string]*nodev1.RuntimeClass) *nodev1.RuntimeClass {
+	if runtimeClass, ok := cache[name]; ok {
+		return runtimeClass
+	}
+
+	runtimeClass, err := f.clientset.NodeV1().RuntimeClasses().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"runtime_class": name,
+			"error":         err.Error(),
+		}).Warn("Failed to fetch RuntimeClass; its overhead and scheduling constraints are ignored")
+		runtimeClass = nil
 	}
 
-	return types.PodInfo{
-		Name:           pod.Name,
-		Namespace:      pod.Namespace,
-		RequestsCPU:    totalRequestsCPU,
-		RequestsMemory: totalRequestsMemory,
-		LimitsCPU:      totalLimitsCPU,
-		LimitsMemory:   totalLimitsMemory,
-		NodeAffinity:   nodeAffinity,
-		Tolerations:    pod.Spec.Tolerations,
+	cache[name] = runtimeClass
+	return runtimeClass
+}
+
+// fetchPriorityClass looks up a PriorityClass by name, caching the result for the
+// remainder of the fetch. Failures are logged and yield nil, so the pod keeps priority 0.
+//
+// Parameters:
+//   - ctx: Context for the API request
+//   - name: The PriorityClass name referenced by the pod
+//   - cache: PriorityClasses already resolved during this fetch, keyed by name
+//
+// Returns:
+//   - *schedulingv1.PriorityClass: The PriorityClass, or nil if it could not be fetched
+func (f *Fetcher) fetchPriorityClass(ctx context.Context, name string,
+	cache map[string]*schedulingv1.PriorityClass) *schedulingv1.PriorityClass {
+	if priorityClass, ok := cache[name]; ok {
+		return priorityClass
+	}
+
+	priorityClass, err := f.clientset.SchedulingV1().PriorityClasses().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"priority_class": name,
+			"error":          err.Error(),
+		}).Warn("Failed to fetch PriorityClass; the pod is treated as priority 0")
+		priorityClass = nil
+	}
+
+	cache[name] = priorityClass
+	return priorityClass
+}
+
+// applyPriorityClass resolves the pod's priority and preemption policy from its
+// PriorityClass, as the Priority admission plugin does for pods that have not been
+// admitted with spec.priority set.
+//
+// Parameters:
+//   - podInfo: The parsed pod information
+//   - priorityClass: The PriorityClass referenced by the pod
+//
+// Returns:
+//   - types.PodInfo: The pod information with Priority and PreemptionPolicy set
+func applyPriorityClass(podInfo types.PodInfo, priorityClass *schedulingv1.PriorityClass) types.PodInfo {
+	podInfo.Priority = priorityClass.Value
+	if podInfo.PreemptionPolicy == "" && priorityClass.PreemptionPolicy != nil {
+		podInfo.PreemptionPolicy = string(*priorityClass.PreemptionPolicy)
+	}
+	return podInfo
+}
+
+// applyRuntimeClass merges the scheduling constraints of a RuntimeClass into the pod, the
+// same way the RuntimeClass admission controller does. The overhead is not taken from the
+// RuntimeClass: the scheduler only charges spec.overhead, which admission fills in when the
+// pod is created, so a pod created before the RuntimeClass declared an overhead is only logged.
+//
+// Parameters:
+//   - podInfo: The parsed pod information
+//   - runtimeClass: The RuntimeClass referenced by the pod
+//
+// Returns:
+//   - types.PodInfo: The pod information with node selector, tolerations and overhead merged
+func applyRuntimeClass(podInfo types.PodInfo, runtimeClass *nodev1.RuntimeClass) types.PodInfo {
+	if len(podInfo.Overhead) == 0 && runtimeClass.Overhead != nil && len(runtimeClass.Overhead.PodFixed) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"pod_name":      podInfo.Name,
+			"pod_namespace": podInfo.Namespace,
+			"runtime_class": runtimeClass.Name,
+		}).Warn("RuntimeClass declares an overhead the pod was admitted without; the scheduler does not charge it")
+	}
+
+	if runtimeClass.Scheduling == nil {
+		return podInfo
+	}
+
+	if len(runtimeClass.Scheduling.NodeSelector) > 0 {
+		nodeSelector := make(map[string]string, len(podInfo.NodeSelector)+len(runtimeClass.Scheduling.NodeSelector))
+		for key, value := range runtimeClass.Scheduling.NodeSelector {
+			nodeSelector[key] = value
+		}
+		for key, value := range podInfo.NodeSelector {
+			nodeSelector[key] = value
+		}
+		podInfo.NodeSelector = nodeSelector
+	}
+
+	for _, toleration := range runtimeClass.Scheduling.Tolerations {
+		if !containsToleration(podInfo.Tolerations, toleration) {
+			podInfo.Tolerations = append(podInfo.Tolerations, toleration)
+		}
+	}
+
+	return podInfo
+}
+
+func containsToleration(tolerations []corev1.Toleration, toleration corev1.Toleration) bool {
+	for i := range tolerations {
+		if tolerations[i].MatchToleration(&toleration) {
+			return true
+		}
+	}
+	return false
+}
+
+func containerRequests(container corev1.Container) corev1.ResourceList {
+	return container.Resources.Requests
+}
+
+func containerLimits(container corev1.Container) corev1.ResourceList {
+	return container.Resources.Limits
+}
+
+// podResourceNames returns every resource name requested or limited by any app or init
+// container of the pod, in a stable order.
+func podResourceNames(spec corev1.PodSpec) []corev1.ResourceName {
+	seen := make(map[corev1.ResourceName]bool)
+	var names []corev1.ResourceName
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, list := range []corev1.ResourceList{container.Resources.Requests, container.Resources.Limits} {
+			for name := range list {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// memoryBackedEmptyDirLimit sums the sizeLimit of emptyDir volumes with medium Memory.
+// Files written to such volumes are charged to the memory cgroup of the writing container,
+// i.e. they already count against that container's memory limit, and the scheduler does not
+// account for them. The total is only reported, never added to the pod's requests or limits.
+func memoryBackedEmptyDirLimit(spec corev1.PodSpec) resource.Quantity {
+	var total resource.Quantity
+	for _, volume := range spec.Volumes {
+		emptyDir := volume.EmptyDir
+		if emptyDir == nil || emptyDir.Medium != corev1.StorageMediumMemory || emptyDir.SizeLimit == nil {
+			continue
+		}
+		total.Add(*emptyDir.SizeLimit)
+	}
+	return total
+}
+
+// effectivePodResource computes the pod-level amount of a resource using the same formula
+// as the Kubernetes scheduler: the larger of the sum of app containers and the peak reached
+// while init containers run. Restartable init containers (sidecars with restartPolicy
+// Always) keep running after they start, so they are added to every later init container
+// and to the app container sum, as described in KEP-753.
+//
+// Parameters:
+//   - spec: The pod specification
+//   - name: The resource to compute, e.g. cpu or memory
+//   - resources: Selects either the requests or the limits of a container
+//
+// Returns:
+//   - resource.Quantity: The effective pod-level amount
+//   - string: Which container(s) drive the amount, e.g. "app containers" or "init container migrate"
+func effectivePodResource(spec corev1.PodSpec, name corev1.ResourceName,
+	resources func(corev1.Container) corev1.ResourceList) (resource.Quantity, string) {
+	var appTotal resource.Quantity
+	for _, container := range spec.Containers {
+		if quantity, ok := resources(container)[name]; ok {
+			appTotal.Add(quantity)
+		}
+	}
+
+	var sidecarTotal, initPeak resource.Quantity
+	var initPeakSource string
+	for _, container := range spec.InitContainers {
+		quantity := resources(container)[name]
+
+		var running resource.Quantity
+		if container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			sidecarTotal.Add(quantity)
+			running = sidecarTotal.DeepCopy()
+		} else {
+			running = quantity.DeepCopy()
+			running.Add(sidecarTotal)
+		}
+
+		if running.Cmp(initPeak) > 0 {
+			initPeak = running
+			initPeakSource = "init container " + container.Name
+		}
+	}
+
+	total := appTotal.DeepCopy()
+	total.Add(sidecarTotal)
+	source := "app containers"
+	if !sidecarTotal.IsZero() {
+		source = "app containers and sidecar containers"
+	}
+
+	if initPeak.Cmp(total) > 0 {
+		return initPeak, initPeakSource
+	}
+	if total.IsZero() {
+		return total, ""
 	}
+	return total, source
 }
@@ -3,13 +3,20 @@ package internal
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
@@ -36,6 +43,7 @@ func TestFetchNodes_Success(t *testing.T) {
 			},
 		},
 		Spec: corev1.NodeSpec{
+			Unschedulable: true,
 			Taints: []corev1.Taint{
 				{
 					Key:    "node-role.kubernetes.io/master",
@@ -56,6 +64,11 @@ func TestFetchNodes_Success(t *testing.T) {
 			Allocatable: corev1.ResourceList{
 				corev1.ResourceCPU:    resource.MustParse("4"),
 				corev1.ResourceMemory: resource.MustParse("8Gi"),
+				corev1.ResourcePods:   resource.MustParse("110"),
+				"nvidia.com/gpu":      resource.MustParse("2"),
+			},
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionFalse},
 			},
 		},
 	}
@@ -74,13 +87,39 @@ func TestFetchNodes_Success(t *testing.T) {
 	assert.True(t, resource.MustParse("4Gi").Equal(nodes[0].AllocatableMemory))
 	assert.Len(t, nodes[0].Taints, 1)
 	assert.Equal(t, "node-role.kubernetes.io/master", nodes[0].Taints[0].Key)
+	assert.True(t, nodes[0].Unschedulable)
 
 	assert.Equal(t, "node2", nodes[1].Name)
 	assert.True(t, resource.MustParse("4").Equal(nodes[1].AllocatableCPU))
 	assert.True(t, resource.MustParse("8Gi").Equal(nodes[1].AllocatableMemory))
+	assert.True(t, resource.MustParse("2").Equal(nodes[1].Allocatable["nvidia.com/gpu"]))
+	assert.Equal(t, int64(110), nodes[1].AllocatablePods)
+	assert.False(t, nodes[1].Unschedulable)
+	require.Len(t, nodes[1].Conditions, 1)
+	assert.Equal(t, corev1.ConditionFalse, nodes[1].Conditions[0].Status)
 	assert.Len(t, nodes[1].Taints, 0)
 }
 
+func TestFetchNodes_VolumeLimits(t *testing.T) {
+	count := int32(25)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	csiNode := &storagev1.CSINode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Spec: storagev1.CSINodeSpec{
+			Drivers: []storagev1.CSINodeDriver{
+				{Name: "ebs.csi.aws.com", NodeID: "i-0123", Allocatable: &storagev1.VolumeNodeResources{Count: &count}},
+				{Name: "efs.csi.aws.com", NodeID: "i-0123"},
+			},
+		},
+	}
+
+	nodes, err := NewFetcher(fake.NewSimpleClientset(node, csiNode)).FetchNodes(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, map[string]int64{"ebs.csi.aws.com": 25}, nodes[0].VolumeLimits)
+}
+
 func TestFetchPendingPods_ClusterWide(t *testing.T) {
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -123,6 +162,9 @@ func TestFetchPendingPods_ClusterWide(t *testing.T) {
 					},
 				},
 			},
+			NodeSelector: map[string]string{
+				"pool": "general",
+			},
 			Tolerations: []corev1.Toleration{
 				{
 					Key:      "node.kubernetes.io/not-ready",
@@ -150,6 +192,7 @@ func TestFetchPendingPods_ClusterWide(t *testing.T) {
 	assert.True(t, resource.MustParse("200m").Equal(podInfo.LimitsCPU))
 	assert.True(t, resource.MustParse("256Mi").Equal(podInfo.LimitsMemory))
 	assert.NotNil(t, podInfo.NodeAffinity)
+	assert.Equal(t, map[string]string{"pool": "general"}, podInfo.NodeSelector)
 	assert.Len(t, podInfo.Tolerations, 1)
 }
 
@@ -193,7 +236,748 @@ func TestFetchPendingPods_SpecificNamespace(t *testing.T) {
 	assert.True(t, resource.MustParse("1Gi").Equal(podInfo.RequestsMemory))
 }
 
+func TestFetchPendingPods_RuntimeClass(t *testing.T) {
+	runtimeClassName := "kata"
+	runtimeClass := &nodev1.RuntimeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: runtimeClassName},
+		Handler:    "kata",
+		Overhead: &nodev1.Overhead{
+			PodFixed: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+				corev1.ResourceMemory: resource.MustParse("160Mi"),
+			},
+		},
+		Scheduling: &nodev1.Scheduling{
+			NodeSelector: map[string]string{"runtime": "kata"},
+			Tolerations: []corev1.Toleration{
+				{Key: "kata", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kata-pod",
+			Namespace: "default",
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+		},
+		Spec: corev1.PodSpec{
+			RuntimeClassName: &runtimeClassName,
+			Overhead:         runtimeClass.Overhead.PodFixed,
+			NodeSelector:     map[string]string{"zone": "a"},
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("500m"),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	fetcher := NewFetcher(fake.NewSimpleClientset(runtimeClass, pod))
+
+	pods, err := fetcher.FetchPendingPods(context.Background(), "default")
+
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+
+	podInfo := pods[0]
+	assert.Equal(t, "kata", podInfo.RuntimeClassName)
+	assert.True(t, resource.MustParse("750m").Equal(podInfo.RequestsCPU))
+	assert.True(t, resource.MustParse("1184Mi").Equal(podInfo.RequestsMemory))
+	assert.Equal(t, map[string]string{"zone": "a", "runtime": "kata"}, podInfo.NodeSelector)
+	require.Len(t, podInfo.Tolerations, 1)
+	assert.Equal(t, "kata", podInfo.Tolerations[0].Key)
+}
+
+func TestFetchPendingPods_RuntimeClassOverheadNotAdmitted(t *testing.T) {
+	runtimeClassName := "kata"
+	runtimeClass := &nodev1.RuntimeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: runtimeClassName},
+		Handler:    "kata",
+		Overhead: &nodev1.Overhead{
+			PodFixed: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kata-pod",
+			Namespace: "default",
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+		},
+		Spec: corev1.PodSpec{
+			RuntimeClassName: &runtimeClassName,
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("500m"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	fetcher := NewFetcher(fake.NewSimpleClientset(runtimeClass, pod))
+
+	pods, err := fetcher.FetchPendingPods(context.Background(), "default")
+
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.True(t, resource.MustParse("500m").Equal(pods[0].RequestsCPU))
+	assert.Empty(t, pods[0].Overhead)
+}
+
+func TestFetchPendingPods_MissingRuntimeClass(t *testing.T) {
+	runtimeClassName := "gvisor"
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gvisor-pod",
+			Namespace: "default",
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+		},
+		Spec: corev1.PodSpec{
+			RuntimeClassName: &runtimeClassName,
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("500m"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	fetcher := NewFetcher(fake.NewSimpleClientset(pod))
+
+	pods, err := fetcher.FetchPendingPods(context.Background(), "default")
+
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.True(t, resource.MustParse("500m").Equal(pods[0].RequestsCPU))
+	assert.Empty(t, pods[0].Overhead)
+}
+
+func TestFetchPendingPods_VolumeClaims(t *testing.T) {
+	zonal := "zonal"
+	waitForFirstConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	zoneA := &corev1.NodeSelector{
+		NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key:      "topology.kubernetes.io/zone",
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{"a"},
+			}},
+		}},
+	}
+	allowedTopologies := []corev1.TopologySelectorTerm{{
+		MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{{
+			Key:    "topology.kubernetes.io/zone",
+			Values: []string{"b"},
+		}},
+	}}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "db"}},
+			Volumes: []corev1.Volume{
+				{Name: "data", VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-db-0"},
+				}},
+				{Name: "scratch", VolumeSource: corev1.VolumeSource{
+					Ephemeral: &corev1.EphemeralVolumeSource{},
+				}},
+				{Name: "backup", VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "backup"},
+				}},
+				{Name: "config", VolumeSource: corev1.VolumeSource{
+					EmptyDir: &corev1.EmptyDirVolumeSource{},
+				}},
+			},
+		},
+	}
+	bound := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-db-0", Namespace: "default"},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-1", StorageClassName: &zonal},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+	}
+	unbound := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-0-scratch", Namespace: "default"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &zonal},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+	volume := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec: corev1.PersistentVolumeSpec{
+			NodeAffinity: &corev1.VolumeNodeAffinity{Required: zoneA},
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: "ebs.csi.aws.com", VolumeHandle: "vol-0123"},
+			},
+		},
+	}
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta:        metav1.ObjectMeta{Name: zonal},
+		Provisioner:       "ebs.csi.aws.com",
+		VolumeBindingMode: &waitForFirstConsumer,
+		AllowedTopologies: allowedTopologies,
+	}
+
+	fetcher := NewFetcher(fake.NewSimpleClientset(pod, bound, unbound, volume, storageClass))
+
+	pods, err := fetcher.FetchPendingPods(context.Background(), "default")
+
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, []types.VolumeClaimInfo{
+		{Name: "data-db-0", Phase: "Bound", VolumeName: "pv-1", StorageClassName: zonal, NodeAffinity: zoneA,
+			Driver: "ebs.csi.aws.com"},
+		{Name: "db-0-scratch", Phase: "Pending", StorageClassName: zonal, VolumeBindingMode: "WaitForFirstConsumer",
+			AllowedTopologies: allowedTopologies, Driver: "ebs.csi.aws.com"},
+		{Name: "backup", Phase: volumeClaimMissing},
+	}, pods[0].VolumeClaims)
+}
+
+func TestFetchPendingPods_UnresolvedStorageClass(t *testing.T) {
+	missing := "missing"
+	standard := "standard"
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "db"}},
+			Volumes: []corev1.Volume{
+				{Name: "data", VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+				}},
+				{Name: "logs", VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "logs"},
+				}},
+			},
+		},
+	}
+	unresolved := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &missing},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+	immediate := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: "default"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &standard},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+	storageClass := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: standard}, Provisioner: "ebs.csi.aws.com"}
+
+	pods, err := NewFetcher(fake.NewSimpleClientset(pod, unresolved, immediate, storageClass)).
+		FetchPendingPods(context.Background(), "default")
+
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, []types.VolumeClaimInfo{
+		{Name: "data", Phase: "Pending", StorageClassName: missing},
+		{Name: "logs", Phase: "Pending", StorageClassName: standard, VolumeBindingMode: "Immediate",
+			Driver: "ebs.csi.aws.com"},
+	}, pods[0].VolumeClaims)
+
+	conflicts := volumeConflicts(pods[0].VolumeClaims)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, `persistentvolumeclaim "logs" is not bound`, conflicts[0].description)
+}
+
+func TestFetchScheduledPods_VolumeClaimsListedOnce(t *testing.T) {
+	claimPod := func(namespace, name string, claimNames ...string) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       corev1.PodSpec{NodeName: "node-1", Containers: []corev1.Container{{Name: "app"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		for _, claimName := range claimNames {
+			pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{Name: claimName, VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+			}})
+		}
+		return pod
+	}
+	boundClaim := func(namespace, name, volumeName string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: volumeName},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+		}
+	}
+	csiVolume := func(name string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: "ebs.csi.aws.com", VolumeHandle: name},
+			}},
+		}
+	}
+
+	clientset := fake.NewSimpleClientset(
+		claimPod("default", "db-0", "data-0", "logs-0"),
+		claimPod("team-a", "db-1", "data-1"),
+		boundClaim("default", "data-0", "pv-0"),
+		boundClaim("default", "logs-0", "pv-1"),
+		boundClaim("team-a", "data-1", "pv-2"),
+		csiVolume("pv-0"), csiVolume("pv-1"), csiVolume("pv-2"),
+	)
+	clientset.ClearActions()
+
+	pods, err := NewFetcher(clientset).FetchScheduledPods(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, pods, 2)
+	for _, pod := range pods {
+		for _, claim := range pod.VolumeClaims {
+			assert.Equal(t, "ebs.csi.aws.com", claim.Driver, claim.Name)
+		}
+	}
+
+	requests := make(map[string]int)
+	for _, action := range clientset.Actions() {
+		requests[action.GetVerb()+" "+action.GetResource().Resource]++
+	}
+	assert.Equal(t, map[string]int{
+		"list pods":                   1,
+		"list persistentvolumeclaims": 1,
+		"list persistentvolumes":      1,
+	}, requests)
+}
+
+func TestFetchScheduledPods_NoVolumeClaims(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "node-1", Containers: []corev1.Container{{Name: "web"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	})
+	clientset.ClearActions()
+
+	_, err := NewFetcher(clientset).FetchScheduledPods(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, clientset.Actions(), 1)
+	assert.Equal(t, "pods", clientset.Actions()[0].GetResource().Resource)
+}
+
+func TestFetchPendingPods_PriorityClass(t *testing.T) {
+	preemptNever := corev1.PreemptNever
+	priorityClass := &schedulingv1.PriorityClass{
+		ObjectMeta:       metav1.ObjectMeta{Name: "critical"},
+		Value:            100000,
+		PreemptionPolicy: &preemptNever,
+	}
+	admitted := int32(500)
+	pods := []runtime.Object{
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "unadmitted", Namespace: "default"},
+			Spec:       corev1.PodSpec{PriorityClassName: "critical"},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "admitted", Namespace: "default"},
+			Spec:       corev1.PodSpec{PriorityClassName: "critical", Priority: &admitted},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+	}
+
+	fetcher := NewFetcher(fake.NewSimpleClientset(append(pods, priorityClass)...))
+
+	result, err := fetcher.FetchPendingPods(context.Background(), "default")
+
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	byName := map[string]types.PodInfo{result[0].Name: result[0], result[1].Name: result[1]}
+	assert.Equal(t, int32(100000), byName["unadmitted"].Priority)
+	assert.Equal(t, "Never", byName["unadmitted"].PreemptionPolicy)
+	assert.Equal(t, int32(500), byName["admitted"].Priority)
+	assert.Equal(t, "critical", byName["admitted"].PriorityClassName)
+}
+
+func TestFetchPodDisruptionBudgets(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	budget := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: selector},
+		Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+	}
+
+	budgets, err := NewFetcher(fake.NewSimpleClientset(budget)).FetchPodDisruptionBudgets(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []types.PodDisruptionBudgetInfo{
+		{Name: "web", Namespace: "default", Selector: selector, DisruptionsAllowed: 1},
+	}, budgets)
+}
+
+func TestFetchSchedulerLeases(t *testing.T) {
+	lease := func(name, holder string, renewed time.Duration) *coordinationv1.Lease {
+		duration := int32(15)
+		renewTime := metav1.NewMicroTime(time.Now().Add(-renewed))
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceSystem},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &duration,
+				RenewTime:            &renewTime,
+			},
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		lease("batch-scheduler", "batch-scheduler-7d9f_1", time.Second),
+		lease("retired-scheduler", "retired-scheduler-5c4b_1", time.Hour),
+		lease("released-scheduler", "", time.Second),
+		&coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "other-namespace", Namespace: "default"}},
+	)
+
+	names, err := NewFetcher(clientset).FetchSchedulerLeases(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"batch-scheduler"}, names)
+}
+
+func TestFetchPendingPods_SchedulerReports(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 Insufficient cpu.",
+			}},
+		},
+	}
+	event := func(name, message string, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web-0"},
+			Reason:         "FailedScheduling",
+			Message:        message,
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+	older := event("web-0.1", "0/3 nodes are available: 3 Insufficient memory.", time.Now().Add(-time.Hour))
+	newer := event("web-0.2", "0/3 nodes are available: 3 Insufficient cpu.", time.Now())
+	unrelated := event("web-0.3", "Successfully assigned default/web-0 to node-1", time.Now())
+	unrelated.Reason = "Scheduled"
+
+	pods, err := NewFetcher(fake.NewSimpleClientset(pod, older, newer, unrelated)).FetchPendingPods(context.Background(), "default")
+
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	require.Len(t, pods[0].SchedulerReports, 2)
+	assert.Equal(t, schedulerReportCondition, pods[0].SchedulerReports[0].Source)
+	assert.Equal(t, schedulerReportEvent, pods[0].SchedulerReports[1].Source)
+	assert.Equal(t, "0/3 nodes are available: 3 Insufficient cpu.", pods[0].SchedulerReports[1].Message)
+	assert.Equal(t, map[string]int{"Insufficient cpu": 3}, pods[0].SchedulerReports[1].NodeReasons)
+}
+
+func TestFetchPendingPods_WaitingContainers(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "node1"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			InitContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "migrate",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+			}},
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "app",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+					Reason:  "ImagePullBackOff",
+					Message: `Back-off pulling image "app:v2"`,
+				}},
+			}},
+		},
+	}
+
+	pods, err := NewFetcher(fake.NewSimpleClientset(pod)).FetchPendingPods(context.Background(), "default")
+
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, "node1", pods[0].NodeName)
+	assert.Equal(t, []types.ContainerWaiting{
+		{Container: "app", Reason: "ImagePullBackOff", Message: `Back-off pulling image "app:v2"`},
+	}, pods[0].WaitingContainers)
+}
+
+func TestFetchNamespaceLabels(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tier": "prod"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "sandbox"}},
+	)
+	fetcher := NewFetcher(clientset)
+
+	namespaceLabels, err := fetcher.FetchNamespaceLabels(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, namespaceLabels, 2)
+	assert.Equal(t, map[string]string{"tier": "prod"}, namespaceLabels["team-a"])
+	assert.Contains(t, namespaceLabels, "sandbox")
+}
+
+func TestParsePodResources_PodAffinity(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-0",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "web"},
+		},
+		Spec: corev1.PodSpec{
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule},
+			},
+			Affinity: &corev1.Affinity{
+				PodAffinity: &corev1.PodAffinity{},
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+						{
+							LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+							TopologyKey:   "kubernetes.io/hostname",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result := NewFetcher(fake.NewSimpleClientset()).parsePodResources(pod)
+
+	assert.Equal(t, map[string]string{"app": "web"}, result.Labels)
+	assert.NotNil(t, result.PodAffinity)
+	require.NotNil(t, result.PodAntiAffinity)
+	assert.Len(t, result.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+	assert.Len(t, result.TopologySpreadConstraints, 1)
+}
+
+func TestParsePodResources_HostPorts(t *testing.T) {
+	restartAlways := corev1.ContainerRestartPolicyAlways
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "controller-0", Namespace: "ingress"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{
+					Name:  "setup",
+					Ports: []corev1.ContainerPort{{ContainerPort: 9000, HostPort: 9000}},
+				},
+				{
+					Name:          "proxy",
+					RestartPolicy: &restartAlways,
+					Ports:         []corev1.ContainerPort{{ContainerPort: 15001, HostPort: 15001}},
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name: "controller",
+					Ports: []corev1.ContainerPort{
+						{Name: "http", ContainerPort: 8080, HostPort: 80, Protocol: corev1.ProtocolTCP},
+						{Name: "metrics", ContainerPort: 10254},
+					},
+				},
+			},
+		},
+	}
+
+	result := NewFetcher(fake.NewSimpleClientset()).parsePodResources(pod)
+
+	assert.Equal(t, []corev1.ContainerPort{
+		{ContainerPort: 15001, HostPort: 15001},
+		{Name: "http", ContainerPort: 8080, HostPort: 80, Protocol: corev1.ProtocolTCP},
+	}, result.HostPorts)
+}
+
+func TestFetchScheduledPods(t *testing.T) {
+	runningPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "running-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node1",
+			Containers: []corev1.Container{
+				{
+					Name: "container1",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("250m"),
+							corev1.ResourceMemory: resource.MustParse("512Mi"),
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+
+	completedPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "completed-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node1",
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodSucceeded,
+		},
+	}
+
+	unboundPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unbound-pod",
+			Namespace: "default",
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+		},
+	}
+
+	clientset := fake.NewSimpleClientset(runningPod, completedPod, unboundPod)
+	fetcher := NewFetcher(clientset)
+	ctx := context.Background()
+
+	pods, err := fetcher.FetchScheduledPods(ctx)
+
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, "running-pod", pods[0].Name)
+	assert.Equal(t, "node1", pods[0].NodeName)
+	assert.True(t, resource.MustParse("250m").Equal(pods[0].RequestsCPU))
+	assert.True(t, resource.MustParse("512Mi").Equal(pods[0].RequestsMemory))
+}
+
+func TestParsePodResources_ExtendedResources(t *testing.T) {
+	sizeLimit := resource.MustParse("2Gi")
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "extended-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{
+					Name: "scratch",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory, SizeLimit: &sizeLimit},
+					},
+				},
+				{
+					Name: "disk",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &sizeLimit},
+					},
+				},
+			},
+			InitContainers: []corev1.Container{
+				{
+					Name: "unpack",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceEphemeralStorage: resource.MustParse("20Gi"),
+						},
+					},
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name: "trainer",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:              resource.MustParse("1"),
+							corev1.ResourceMemory:           resource.MustParse("4Gi"),
+							corev1.ResourceEphemeralStorage: resource.MustParse("5Gi"),
+							"hugepages-2Mi":                 resource.MustParse("512Mi"),
+							"nvidia.com/gpu":                resource.MustParse("1"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+							"hugepages-2Mi":       resource.MustParse("512Mi"),
+							"nvidia.com/gpu":      resource.MustParse("1"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result := NewFetcher(fake.NewSimpleClientset()).parsePodResources(pod)
+
+	assert.True(t, resource.MustParse("1").Equal(result.Requests[corev1.ResourceCPU]))
+	assert.True(t, resource.MustParse("20Gi").Equal(result.Requests[corev1.ResourceEphemeralStorage]))
+	assert.True(t, resource.MustParse("512Mi").Equal(result.Requests["hugepages-2Mi"]))
+	assert.True(t, resource.MustParse("1").Equal(result.Requests["nvidia.com/gpu"]))
+	assert.True(t, resource.MustParse("4Gi").Equal(result.Limits[corev1.ResourceMemory]))
+	assert.True(t, resource.MustParse("4Gi").Equal(result.LimitsMemory))
+	assert.True(t, resource.MustParse("4Gi").Equal(result.RequestsMemory))
+	require.NotNil(t, result.MemoryBackedEmptyDir)
+	assert.Equal(t, "2Gi", result.MemoryBackedEmptyDir.String())
+}
+
+func TestParsePodResources_MemoryBackedEmptyDirWithoutLimits(t *testing.T) {
+	sizeLimit := resource.MustParse("1Gi")
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cache-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{
+					Name: "cache",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory, SizeLimit: &sizeLimit},
+					},
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result := NewFetcher(fake.NewSimpleClientset()).parsePodResources(pod)
+
+	assert.Empty(t, result.Limits)
+	assert.True(t, result.LimitsMemory.IsZero())
+	require.NotNil(t, result.MemoryBackedEmptyDir)
+	assert.Equal(t, "1Gi", result.MemoryBackedEmptyDir.String())
+
+	_, podMemory, resourceType := podRequirements(result, true)
+	assert.Equal(t, "4Gi", podMemory.String())
+	assert.Equal(t, "requests", resourceType)
+}
+
 func TestParsePodResources(t *testing.T) {
+	restartAlways := corev1.ContainerRestartPolicyAlways
+
 	tests := []struct {
 		name     string
 		pod      corev1.Pod
@@ -224,12 +1008,14 @@ func TestParsePodResources(t *testing.T) {
 				},
 			},
 			expected: types.PodInfo{
-				Name:           "test-pod",
-				Namespace:      "test-ns",
-				RequestsCPU:    resource.MustParse("100m"),
-				RequestsMemory: resource.MustParse("128Mi"),
-				LimitsCPU:      resource.MustParse("200m"),
-				LimitsMemory:   resource.MustParse("256Mi"),
+				Name:                 "test-pod",
+				Namespace:            "test-ns",
+				RequestsCPU:          resource.MustParse("100m"),
+				RequestsMemory:       resource.MustParse("128Mi"),
+				RequestsCPUSource:    "app containers",
+				RequestsMemorySource: "app containers",
+				LimitsCPU:            resource.MustParse("200m"),
+				LimitsMemory:         resource.MustParse("256Mi"),
 			},
 		},
 		{
@@ -261,10 +1047,12 @@ func TestParsePodResources(t *testing.T) {
 				},
 			},
 			expected: types.PodInfo{
-				Name:           "multi-container-pod",
-				Namespace:      "default",
-				RequestsCPU:    resource.MustParse("300m"),
-				RequestsMemory: resource.MustParse("384Mi"),
+				Name:                 "multi-container-pod",
+				Namespace:            "default",
+				RequestsCPU:          resource.MustParse("300m"),
+				RequestsMemory:       resource.MustParse("384Mi"),
+				RequestsCPUSource:    "app containers",
+				RequestsMemorySource: "app containers",
 			},
 		},
 		{
@@ -287,6 +1075,142 @@ func TestParsePodResources(t *testing.T) {
 				Namespace: "default",
 			},
 		},
+		{
+			name: "pod overhead added to requests and set limits",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "overhead-pod",
+					Namespace: "default",
+				},
+				Spec: corev1.PodSpec{
+					Overhead: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("250m"),
+						corev1.ResourceMemory: resource.MustParse("120Mi"),
+					},
+					Containers: []corev1.Container{
+						{
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("100m"),
+									corev1.ResourceMemory: resource.MustParse("128Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("256Mi"),
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: types.PodInfo{
+				Name:                 "overhead-pod",
+				Namespace:            "default",
+				RequestsCPU:          resource.MustParse("350m"),
+				RequestsMemory:       resource.MustParse("248Mi"),
+				RequestsCPUSource:    "app containers",
+				RequestsMemorySource: "app containers",
+				LimitsMemory:         resource.MustParse("376Mi"),
+			},
+		},
+		{
+			name: "init container larger than app containers",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "init-pod",
+					Namespace: "default",
+				},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{
+							Name: "migrate",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("50m"),
+									corev1.ResourceMemory: resource.MustParse("16Gi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("16Gi"),
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("100m"),
+									corev1.ResourceMemory: resource.MustParse("128Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("256Mi"),
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: types.PodInfo{
+				Name:                 "init-pod",
+				Namespace:            "default",
+				RequestsCPU:          resource.MustParse("100m"),
+				RequestsMemory:       resource.MustParse("16Gi"),
+				RequestsCPUSource:    "app containers",
+				RequestsMemorySource: "init container migrate",
+				LimitsMemory:         resource.MustParse("16Gi"),
+			},
+		},
+		{
+			name: "sidecar init containers add to later init and app containers",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "sidecar-pod",
+					Namespace: "default",
+				},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{
+							Name:          "proxy",
+							RestartPolicy: &restartAlways,
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("500m"),
+									corev1.ResourceMemory: resource.MustParse("64Mi"),
+								},
+							},
+						},
+						{
+							Name: "setup",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("1"),
+									corev1.ResourceMemory: resource.MustParse("64Mi"),
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("200m"),
+									corev1.ResourceMemory: resource.MustParse("256Mi"),
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: types.PodInfo{
+				Name:                 "sidecar-pod",
+				Namespace:            "default",
+				RequestsCPU:          resource.MustParse("1500m"),
+				RequestsMemory:       resource.MustParse("320Mi"),
+				RequestsCPUSource:    "init container setup",
+				RequestsMemorySource: "app containers and sidecar containers",
+			},
+		},
 	}
 
 	fetcher := NewFetcher(fake.NewSimpleClientset())
@@ -301,6 +1225,50 @@ func TestParsePodResources(t *testing.T) {
 			assert.True(t, tt.expected.RequestsMemory.Equal(result.RequestsMemory))
 			assert.True(t, tt.expected.LimitsCPU.Equal(result.LimitsCPU))
 			assert.True(t, tt.expected.LimitsMemory.Equal(result.LimitsMemory))
+			assert.Equal(t, tt.expected.RequestsCPUSource, result.RequestsCPUSource)
+			assert.Equal(t, tt.expected.RequestsMemorySource, result.RequestsMemorySource)
 		})
 	}
 }
+
+func TestFetchPendingPods_SchedulingGates(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "gated", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			SchedulerName:   "batch-scheduler",
+			SchedulingGates: []corev1.PodSchedulingGate{{Name: "example.com/quota"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+
+	pods, err := NewFetcher(fake.NewSimpleClientset(pod)).FetchPendingPods(context.Background(), "default")
+
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, "batch-scheduler", pods[0].SchedulerName)
+	assert.Equal(t, []string{"example.com/quota"}, pods[0].SchedulingGates)
+}
+
+func TestFetchPods_Nomination(t *testing.T) {
+	deleted := metav1.Now()
+	pending := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "web"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending, NominatedNodeName: "node1"},
+	}
+	victim := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "job-1", Namespace: "web", DeletionTimestamp: &deleted},
+		Spec:       corev1.PodSpec{NodeName: "node1"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	fetcher := NewFetcher(fake.NewSimpleClientset(pending, victim))
+
+	pods, err := fetcher.FetchPendingPods(context.Background(), "web")
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, "node1", pods[0].NominatedNodeName)
+
+	scheduled, err := fetcher.FetchScheduledPods(context.Background())
+	require.NoError(t, err)
+	require.Len(t, scheduled, 1)
+	assert.True(t, scheduled[0].Terminating)
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// wildcardHostIP is the host IP a hostPort binds to when none is given.
+const wildcardHostIP = "0.0.0.0"
+
+// filterByHostPorts removes nodes on which a pod already running there binds one of the
+// pending pod's host ports. Two ports collide when they share the port number and protocol
+// and either binds the wildcard address or both bind the same host IP.
+//
+// Parameters:
+//   - pod: The pod whose host ports are checked
+//   - nodes: Candidate nodes to filter, with their bound pods attached
+//
+// Returns:
+//   - []types.NodeInfo: Nodes on which every host port is free
+//   - nodeExclusion: The excluded nodes, with the colliding ports and pods as details
+func filterByHostPorts(pod types.PodInfo, nodes []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+	exclusion := nodeExclusion{constraint: "host port conflicts", code: types.ReasonCodeHostPortConflict}
+
+	if len(pod.HostPorts) == 0 {
+		return nodes, exclusion
+	}
+
+	kept := make([]types.NodeInfo, 0, len(nodes))
+	ports := make(map[string]bool)
+	for _, node := range nodes {
+		conflicts := hostPortConflicts(pod, node)
+		if len(conflicts) == 0 {
+			kept = append(kept, node)
+			continue
+		}
+
+		exclusion.exclude(node, "node(s) didn't have free ports for the requested pod ports")
+		var parts []string
+		for _, conflict := range conflicts {
+			ports[conflict.port] = true
+			parts = append(parts, fmt.Sprintf("%s used by pod %s", conflict.port, conflict.pod))
+		}
+		exclusion.details = append(exclusion.details, fmt.Sprintf("%s: %s", node.Name, strings.Join(parts, ", ")))
+	}
+
+	if len(exclusion.nodes) == 0 {
+		return kept, exclusion
+	}
+
+	portList := strings.Join(sortedKeys(ports), ", ")
+	exclusion.reason = fmt.Sprintf("all %d node(s) already have a pod using host port(s) %s",
+		len(exclusion.nodes), portList)
+	exclusion.summary = fmt.Sprintf("%d node(s) have no free host port(s) %s", len(exclusion.nodes), portList)
+	exclusion.suggestion = fmt.Sprintf(
+		"Add nodes where host port(s) %s are free, or drop hostPort in favor of a Service", portList)
+
+	return kept, exclusion
+}
+
+// hostPortConflict is a host port of the pending pod that a running pod already binds.
+type hostPortConflict struct {
+	port string
+	pod  string
+}
+
+// hostPortConflicts lists the pending pod's host ports that collide with ports bound by the
+// pods running on a node. Each port is reported once, with the first pod binding it.
+func hostPortConflicts(pod types.PodInfo, node types.NodeInfo) []hostPortConflict {
+	var conflicts []hostPortConflict
+	for _, wanted := range pod.HostPorts {
+		for _, other := range node.Pods {
+			if collidingHostPort(wanted, other.HostPorts) {
+				conflicts = append(conflicts, hostPortConflict{port: formatHostPort(wanted), pod: podKey(other)})
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+// collidingHostPort reports whether a host port collides with any of the used ports.
+func collidingHostPort(wanted corev1.ContainerPort, used []corev1.ContainerPort) bool {
+	for _, port := range used {
+		if port.HostPort != wanted.HostPort || hostPortProtocol(port) != hostPortProtocol(wanted) {
+			continue
+		}
+		wantedIP, usedIP := hostPortIP(wanted), hostPortIP(port)
+		if wantedIP == wildcardHostIP || usedIP == wildcardHostIP || wantedIP == usedIP {
+			return true
+		}
+	}
+	return false
+}
+
+// hostPortProtocol returns the port's protocol, defaulting to TCP.
+func hostPortProtocol(port corev1.ContainerPort) corev1.Protocol {
+	if port.Protocol == "" {
+		return corev1.ProtocolTCP
+	}
+	return port.Protocol
+}
+
+// hostPortIP returns the host IP the port binds to, defaulting to the wildcard address.
+func hostPortIP(port corev1.ContainerPort) string {
+	if port.HostIP == "" {
+		return wildcardHostIP
+	}
+	return port.HostIP
+}
+
+// formatHostPort renders a host port as "80/TCP", prefixed with the host IP when one is set.
+func formatHostPort(port corev1.ContainerPort) string {
+	formatted := fmt.Sprintf("%d/%s", port.HostPort, hostPortProtocol(port))
+	if ip := hostPortIP(port); ip != wildcardHostIP {
+		formatted = ip + ":" + formatted
+	}
+	return formatted
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func hostPortPod(namespace, name string, ports ...corev1.ContainerPort) types.PodInfo {
+	pod := labeledPod(namespace, name, nil)
+	pod.HostPorts = ports
+	return pod
+}
+
+func TestFilterByHostPorts(t *testing.T) {
+	https := corev1.ContainerPort{ContainerPort: 443, HostPort: 443}
+
+	tests := []struct {
+		name            string
+		running         []corev1.ContainerPort
+		wanted          []corev1.ContainerPort
+		expectedKept    []string
+		expectedDetails []string
+	}{
+		{
+			name:         "no host ports",
+			running:      []corev1.ContainerPort{https},
+			expectedKept: []string{"node-1"},
+		},
+		{
+			name:            "same port and protocol",
+			running:         []corev1.ContainerPort{https},
+			wanted:          []corev1.ContainerPort{https},
+			expectedDetails: []string{"node-1: 443/TCP used by pod ingress/controller-0"},
+		},
+		{
+			name:         "different protocol",
+			running:      []corev1.ContainerPort{https},
+			wanted:       []corev1.ContainerPort{{HostPort: 443, Protocol: corev1.ProtocolUDP}},
+			expectedKept: []string{"node-1"},
+		},
+		{
+			name:         "different host IPs",
+			running:      []corev1.ContainerPort{{HostPort: 443, HostIP: "10.0.0.1"}},
+			wanted:       []corev1.ContainerPort{{HostPort: 443, HostIP: "10.0.0.2"}},
+			expectedKept: []string{"node-1"},
+		},
+		{
+			name:            "wildcard host IP collides with a specific one",
+			running:         []corev1.ContainerPort{{HostPort: 443, HostIP: "10.0.0.1"}},
+			wanted:          []corev1.ContainerPort{https},
+			expectedDetails: []string{"node-1: 443/TCP used by pod ingress/controller-0"},
+		},
+		{
+			name:            "specific host IP collides with the wildcard",
+			running:         []corev1.ContainerPort{https},
+			wanted:          []corev1.ContainerPort{{HostPort: 443, HostIP: "10.0.0.1", Protocol: corev1.ProtocolTCP}},
+			expectedDetails: []string{"node-1: 10.0.0.1:443/TCP used by pod ingress/controller-0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := []types.NodeInfo{hostnameNode("node-1", "a", hostPortPod("ingress", "controller-0", tt.running...))}
+
+			kept, exclusion := filterByHostPorts(hostPortPod("ingress", "controller-1", tt.wanted...), nodes)
+
+			if tt.expectedKept == nil {
+				assert.Empty(t, kept)
+			} else {
+				assert.Equal(t, tt.expectedKept, nodeInfoNames(kept))
+			}
+			assert.Equal(t, tt.expectedDetails, exclusion.details)
+		})
+	}
+}
+
+func TestAnalyzeSinglePod_HostPortConflict(t *testing.T) {
+	http := corev1.ContainerPort{ContainerPort: 8080, HostPort: 80}
+	nodes := []types.NodeInfo{
+		hostnameNode("node-1", "a", hostPortPod("ingress", "controller-0", http)),
+		hostnameNode("node-2", "a", hostPortPod("ingress", "controller-1", http)),
+	}
+
+	pod := hostPortPod("ingress", "controller-2", http)
+	pod.RequestsCPU = resource.MustParse("100m")
+	pod.RequestsMemory = resource.MustParse("128Mi")
+
+	result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+	assert.False(t, result.IsSchedulable)
+	assert.Equal(t, "all 2 node(s) already have a pod using host port(s) 80/TCP", result.Reason)
+	assert.Equal(t, "Add nodes where host port(s) 80/TCP are free, or drop hostPort in favor of a Service", result.Suggestion)
+	assert.Equal(t, []string{
+		"node-1: 80/TCP used by pod ingress/controller-0",
+		"node-2: 80/TCP used by pod ingress/controller-1",
+	}, result.HostPortConflicts)
+}
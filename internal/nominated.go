@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+)
+
+// analyzeNominatedPod explains a pending pod that the scheduler has nominated to a node
+// after preempting pods there. The pod waits for its victims to terminate, which is a
+// transient state, so the lower-priority pods still terminating on the node are reported
+// instead of a capacity verdict.
+//
+// Parameters:
+//   - pod: The pending pod with NominatedNodeName set
+//   - nodes: Every node in the cluster, with their bound pods attached
+//
+// Returns:
+//   - types.AnalysisResult: A result in the PreemptionInProgress category
+//   - bool: False if the nominated node no longer exists, so the nomination is stale
+func analyzeNominatedPod(pod types.PodInfo, nodes []types.NodeInfo) (types.AnalysisResult, bool) {
+	nominated := nodeByName(nodes, pod.NominatedNodeName)
+	if nominated == nil {
+		return types.AnalysisResult{}, false
+	}
+
+	result := types.AnalysisResult{
+		Pod:              pod,
+		Category:         types.PendingCategoryPreemptionInProgress,
+		IsSchedulable:    true,
+		SchedulerReports: pod.SchedulerReports,
+	}
+
+	for _, other := range nominated.Pods {
+		if other.Terminating && other.Priority < pod.Priority {
+			result.TerminatingVictims = append(result.TerminatingVictims,
+				fmt.Sprintf("%s (priority %d)", podKey(other), other.Priority))
+		}
+	}
+
+	nominatedNodes := []string{pod.NominatedNodeName}
+	if len(result.TerminatingVictims) == 0 {
+		result.Reasons = []types.ReasonDetail{messageDetail(types.ReasonCodePreemptionInProgress,
+			fmt.Sprintf("preemption in progress: nominated to node %s; no victims are still terminating, "+
+				"so the pod should be bound on the next scheduling attempt", pod.NominatedNodeName), nominatedNodes, nil)}
+		result.Reason = renderReasons(result.Reasons)
+		result.Suggestion = "Wait for the next scheduling attempt; if the pod stays pending, check the scheduler's events for the pod"
+		return result, true
+	}
+
+	result.Reasons = []types.ReasonDetail{messageDetail(types.ReasonCodePreemptionInProgress,
+		fmt.Sprintf("preemption in progress: nominated to node %s, waiting for %d victim(s) to terminate: %s",
+			pod.NominatedNodeName, len(result.TerminatingVictims), strings.Join(result.TerminatingVictims, ", ")),
+		nominatedNodes, result.TerminatingVictims)}
+	result.Reason = renderReasons(result.Reasons)
+	result.Suggestion = "Wait for the victims to terminate; if this takes long, check their " +
+		"terminationGracePeriodSeconds, preStop hooks and finalizers"
+	return result, true
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestAnalyzeNominatedPod(t *testing.T) {
+	terminating := func(pod types.PodInfo) types.PodInfo {
+		pod.Terminating = true
+		return pod
+	}
+
+	tests := []struct {
+		name            string
+		nodes           []types.NodeInfo
+		expectedOK      bool
+		expectedReason  string
+		expectedVictims []string
+	}{
+		{
+			name: "victims still terminating",
+			nodes: []types.NodeInfo{nodeWithPods("node-1",
+				terminating(priorityPod("batch", "job-1", 0, "1", nil)),
+				terminating(priorityPod("batch", "job-2", 100, "500m", nil)),
+				priorityPod("batch", "job-3", 0, "250m", nil),
+				terminating(priorityPod("web", "api-0", 2000, "250m", nil)),
+			)},
+			expectedOK: true,
+			expectedReason: "preemption in progress: nominated to node node-1, waiting for 2 victim(s) to terminate: " +
+				"batch/job-1 (priority 0), batch/job-2 (priority 100)",
+			expectedVictims: []string{"batch/job-1 (priority 0)", "batch/job-2 (priority 100)"},
+		},
+		{
+			name:       "victims already gone",
+			nodes:      []types.NodeInfo{nodeWithPods("node-1", priorityPod("batch", "job-3", 0, "250m", nil))},
+			expectedOK: true,
+			expectedReason: "preemption in progress: nominated to node node-1; no victims are still terminating, " +
+				"so the pod should be bound on the next scheduling attempt",
+		},
+		{
+			name:  "nominated node no longer exists",
+			nodes: []types.NodeInfo{nodeWithPods("node-2")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := priorityPod("web", "api-1", 1000, "1", nil)
+			pod.NominatedNodeName = "node-1"
+
+			result, ok := analyzeNominatedPod(pod, tt.nodes)
+
+			assert.Equal(t, tt.expectedOK, ok)
+			if !tt.expectedOK {
+				return
+			}
+			assert.Equal(t, types.PendingCategoryPreemptionInProgress, result.Category)
+			assert.True(t, result.IsSchedulable)
+			assert.Equal(t, tt.expectedReason, result.Reason)
+			assert.Equal(t, tt.expectedVictims, result.TerminatingVictims)
+		})
+	}
+}
+
+func TestAnalyzePodSchedulability_NominatedPods(t *testing.T) {
+	victim := priorityPod("batch", "job-1", 0, "2", nil)
+	victim.NodeName = "node-1"
+	victim.Terminating = true
+
+	nominated := priorityPod("web", "api-0", 1000, "2", nil)
+	nominated.NominatedNodeName = "node-1"
+	stale := priorityPod("web", "api-1", 1000, "100m", nil)
+	stale.NominatedNodeName = "node-gone"
+
+	nodes := []types.NodeInfo{hostnameNode("node-1", "a")}
+	nodes[0].AllocatableCPU = resource.MustParse("2")
+
+	mockFetcher := &MockFetcher{}
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return([]types.PodInfo{nominated, stale}, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return(nodes, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return([]types.PodInfo{victim}, nil)
+	mockFetcher.On("FetchPodDisruptionBudgets", mock.Anything).Return([]types.PodDisruptionBudgetInfo{}, nil)
+
+	results, err := NewAnalyzer(mockFetcher).AnalyzePodSchedulability(context.Background(), "default", false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, types.PendingCategoryPreemptionInProgress, results[0].Category)
+	assert.Equal(t, []string{"batch/job-1 (priority 0)"}, results[0].TerminatingVictims)
+	assert.Equal(t, types.PendingCategoryUnscheduled, results[1].Category)
+	mockFetcher.AssertExpectations(t)
+}
@@ -0,0 +1,341 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// simulateCollectiveScheduling places the pending pods onto a simulated copy of the nodes, one
+// after another, so that pods which each fit on their own but not together are found. Pods
+// nominated after preemption are placed on their nominated node first, replacing the victims
+// still terminating there. The individually schedulable pods follow in priority order, each
+// on the fitting node the scheduler's default LeastAllocated scoring prefers, reserving its
+// requests as the scheduler does. Pods left without a node are marked unschedulable in
+// place, with how much the closest node falls short. Each of them is then charged to that
+// node, outside the placement, so that the shortfalls of several unplaced pods add up to
+// the capacity the cluster lacks instead of counting the same free capacity again.
+//
+// Parameters:
+//   - results: The per-pod results; unplaced pods are updated in place
+//   - nodes: Every node in the cluster, with their bound pods attached
+//   - includeLimits: If true, uses resource limits instead of requests for comparison
+//
+// Returns:
+//   - int: The number of pods that fit individually but could not be placed together
+func (a *Analyzer) simulateCollectiveScheduling(results []types.AnalysisResult, nodes []types.NodeInfo,
+	includeLimits bool) int {
+	simulated := append([]types.NodeInfo{}, nodes...)
+
+	var queue []int
+	for i, result := range results {
+		switch {
+		case result.Category == types.PendingCategoryPreemptionInProgress:
+			simulated = reserveNominatedNode(result.Pod, simulated)
+		case result.Category == types.PendingCategoryUnscheduled && result.IsSchedulable:
+			queue = append(queue, i)
+		}
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		return results[queue[i]].Pod.Priority > results[queue[j]].Pod.Priority
+	})
+
+	charged := make(map[string]corev1.ResourceList)
+	placed := 0
+	unplaced := 0
+	for _, i := range queue {
+		pod := results[i].Pod
+		podCPU, podMemory, _, extended := a.requirements(pod, includeLimits)
+		candidates, placement := a.placementCandidates(pod, simulated)
+
+		var best *types.NodeInfo
+		bestScore := -1.0
+		for j := range candidates {
+			node := candidates[j]
+			if !a.podFitsNode(pod, node, simulated, podCPU, podMemory, extended) {
+				continue
+			}
+			if score := leastAllocatedScore(node, podCPU, podMemory); score > bestScore {
+				best, bestScore = &candidates[j], score
+			}
+		}
+
+		if best != nil {
+			pod.NodeName = best.Name
+			simulated = replaceNode(simulated, withPod(*best, pod))
+			placed++
+			continue
+		}
+
+		a.markUnplaced(&results[i], candidates, simulated, placement, charged, placed, podCPU, podMemory, extended)
+		unplaced++
+		logrus.WithFields(logrus.Fields{
+			"pod_name":      pod.Name,
+			"pod_namespace": pod.Namespace,
+			"reason":        results[i].Reason,
+		}).Warn("Pod fits on its own but not together with the other pending pods")
+	}
+
+	return unplaced
+}
+
+// placementExclusions holds the nodes removed by each of the checks placing other pods
+// cannot change.
+type placementExclusions struct {
+	availability nodeExclusion
+	selector     nodeExclusion
+	affinity     nodeExclusion
+	taint        nodeExclusion
+	volume       nodeExclusion
+}
+
+// list returns the exclusions in evaluation order, followed by the given ones.
+func (e placementExclusions) list(more ...nodeExclusion) []nodeExclusion {
+	return append([]nodeExclusion{e.availability, e.selector, e.affinity, e.taint, e.volume}, more...)
+}
+
+// placementCandidates returns the simulated nodes that pass the checks placing other pods
+// cannot change: availability, nodeSelector, node affinity, taints and volume topology,
+// together with the nodes each of them removed. Checks whose plugins the pod's scheduler
+// profile disables are skipped; cordoned and NotReady nodes are kept only when both
+// NodeUnschedulable and TaintToleration are disabled. With --include-unavailable-nodes, the
+// taints marking nodes cordoned, not ready or unreachable are ignored as well.
+func (a *Analyzer) placementCandidates(pod types.PodInfo,
+	nodes []types.NodeInfo) ([]types.NodeInfo, placementExclusions) {
+	var exclusions placementExclusions
+	profile := a.profileFor(pod)
+	candidates := nodes
+	if !a.includeUnavailableNodes &&
+		(profile.filterEnabled(nodeUnschedulablePlugin) || profile.filterEnabled(taintTolerationPlugin)) {
+		candidates, exclusions.availability = filterByNodeAvailability(pod, candidates)
+	}
+	candidates, exclusions.selector = a.runFilter(pod, nodeAffinityPlugin, candidates,
+		func(pod types.PodInfo, kept []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+			return filterByNodeSelector(pod, kept, nodes)
+		})
+	candidates, exclusions.affinity = a.runFilter(pod, nodeAffinityPlugin, candidates, filterByNodeAffinity)
+	candidates, exclusions.taint = a.runFilter(pod, taintTolerationPlugin, candidates,
+		func(pod types.PodInfo, kept []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+			if a.includeUnavailableNodes {
+				pod = withAvailabilityTolerations(pod)
+			}
+			return filterByTaints(pod, kept)
+		})
+	candidates, exclusions.volume = a.runFilter(pod, volumeBindingPlugin, candidates, filterByVolumes)
+	return candidates, exclusions
+}
+
+// reserveNominatedNode places a pod with preemption in progress on its nominated node,
+// removing the lower-priority pods still terminating there.
+func reserveNominatedNode(pod types.PodInfo, nodes []types.NodeInfo) []types.NodeInfo {
+	node := nodeByName(nodes, pod.NominatedNodeName)
+	if node == nil {
+		return nodes
+	}
+
+	reserved := *node
+	for _, other := range node.Pods {
+		if other.Terminating && other.Priority < pod.Priority {
+			reserved = withoutPod(reserved, other)
+		}
+	}
+	pod.NodeName = node.Name
+	return replaceNode(nodes, withPod(reserved, pod))
+}
+
+// markUnplaced turns the result of a pod that fits on its own into an unschedulable one,
+// naming the candidate node that comes closest to holding it and what it lacks, and why each
+// simulated node rejects it, and compares it with the scheduler's reports again. The
+// shortfall is measured against the node's free capacity less what the earlier unplaced pods
+// were charged there, and the pod is charged to the closest node in turn.
+func (a *Analyzer) markUnplaced(result *types.AnalysisResult, candidates, simulated []types.NodeInfo,
+	placement placementExclusions, charged map[string]corev1.ResourceList, placed int,
+	podCPU, podMemory resource.Quantity, extended corev1.ResourceList) {
+	pod := result.Pod
+	result.IsSchedulable = false
+	result.FittingNodes = nil
+	result.CollectivelyUnplaced = true
+
+	kept, volumeLimitExclusion := a.runFilter(pod, nodeVolumeLimitsPlugin, candidates, filterByVolumeLimits)
+	kept, hostPortExclusion := a.runFilter(pod, nodePortsPlugin, kept, filterByHostPorts)
+	kept, podAffinityExclusion := a.runFilter(pod, interPodAffinityPlugin, kept, a.podAffinityFilter(simulated))
+	kept, spreadExclusion := a.runFilter(pod, podTopologySpreadPlugin, kept,
+		func(pod types.PodInfo, kept []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+			return filterByTopologySpread(pod, kept, simulated)
+		})
+	kept, podLimitExclusion := a.runFilter(pod, nodeResourcesFitPlugin, kept,
+		func(_ types.PodInfo, kept []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+			return filterByPodCapacity(kept)
+		})
+	exclusions := placement.list(volumeLimitExclusion, hostPortExclusion, podAffinityExclusion, spreadExclusion,
+		podLimitExclusion)
+	result.NodeRejections, result.RejectionSummary = nodeRejections(exclusions,
+		a.evaluateNodeFits(kept, podCPU, podMemory, extended), len(simulated))
+	result.SchedulerDisagreement = schedulerDisagreement(*result, len(simulated))
+
+	var closest string
+	bestRatio := -1.0
+	for _, node := range candidates {
+		shortfall := resourceShortfall(withCharge(node, charged[node.Name]), podCPU, podMemory, extended)
+		if len(shortfall) == 0 {
+			continue
+		}
+		if ratio := shortfallRatio(node, shortfall); bestRatio < 0 || ratio < bestRatio {
+			closest, bestRatio, result.Shortfall = node.Name, ratio, shortfall
+		}
+	}
+
+	if closest == "" {
+		result.Reasons = []types.ReasonDetail{messageDetail(types.ReasonCodeCollectivelyUnplaced,
+			fmt.Sprintf("fits on its own, but no node is left for it once the %d pending pod(s) "+
+				"ahead of it in priority order are placed", placed), nil, nil)}
+		result.Reason = renderReasons(result.Reasons)
+		result.Suggestion = "Add nodes, or relax the host port, pod slot, affinity or topology spread " +
+			"constraints shared by the pending pods"
+		return
+	}
+	charged[closest] = addCharge(charged[closest], podCPU, podMemory, extended)
+
+	detail := messageDetail(types.ReasonCodeCollectivelyUnplaced,
+		fmt.Sprintf("fits on its own, but not once the %d pending pod(s) ahead of it in priority order are placed: "+
+			"the closest node %s is short by %s", placed, closest, formatResourceList(result.Shortfall)),
+		[]string{closest}, nil)
+	for _, name := range sortedResourceNames(result.Shortfall) {
+		requested := extended[name]
+		switch name {
+		case corev1.ResourceCPU:
+			requested = podCPU
+		case corev1.ResourceMemory:
+			requested = podMemory
+		}
+		available := requested.DeepCopy()
+		available.Sub(result.Shortfall[name])
+		detail.Resources = append(detail.Resources,
+			types.ResourceAmount{Name: name, Requested: requested.DeepCopy(), Available: available})
+	}
+	detail.Scope = types.ReasonScopeFree
+	result.Reasons = []types.ReasonDetail{detail}
+	result.Reason = renderReasons(result.Reasons)
+	result.Suggestion = "Add capacity for the pending pods together, or lower their requests; each pod fits " +
+		"alone but the cluster cannot hold all of them at once"
+}
+
+// addCharge adds the pod's CPU, memory and extended resource requirements to a node's charge.
+func addCharge(charge corev1.ResourceList, podCPU, podMemory resource.Quantity,
+	extended corev1.ResourceList) corev1.ResourceList {
+	if charge == nil {
+		charge = make(corev1.ResourceList)
+	}
+	add := func(name corev1.ResourceName, quantity resource.Quantity) {
+		sum := charge[name]
+		sum.Add(quantity)
+		charge[name] = sum
+	}
+
+	add(corev1.ResourceCPU, podCPU)
+	add(corev1.ResourceMemory, podMemory)
+	for name, quantity := range extended {
+		add(name, quantity)
+	}
+	return charge
+}
+
+// withCharge returns a copy of the node whose usage includes the charge of unplaced pods.
+func withCharge(node types.NodeInfo, charge corev1.ResourceList) types.NodeInfo {
+	if len(charge) == 0 {
+		return node
+	}
+
+	updated := copyNodeUsage(node)
+	for name, quantity := range charge {
+		switch name {
+		case corev1.ResourceCPU:
+			updated.RequestedCPU.Add(quantity)
+		case corev1.ResourceMemory:
+			updated.RequestedMemory.Add(quantity)
+		default:
+			requested := updated.Requested[name]
+			requested.Add(quantity)
+			updated.Requested[name] = requested
+		}
+	}
+	return updated
+}
+
+// resourceShortfall returns how much of each resource the node lacks for the pod, or nil
+// if it has enough free capacity.
+func resourceShortfall(node types.NodeInfo, podCPU, podMemory resource.Quantity,
+	extended corev1.ResourceList) corev1.ResourceList {
+	var shortfall corev1.ResourceList
+	add := func(name corev1.ResourceName, required, free resource.Quantity) {
+		if required.Cmp(free) <= 0 {
+			return
+		}
+		if shortfall == nil {
+			shortfall = make(corev1.ResourceList)
+		}
+		missing := required.DeepCopy()
+		missing.Sub(free)
+		shortfall[name] = missing
+	}
+
+	freeCPU, freeMemory := freeResources(node)
+	add(corev1.ResourceCPU, podCPU, freeCPU)
+	add(corev1.ResourceMemory, podMemory, freeMemory)
+	for name, required := range extended {
+		add(name, required, freeResource(node, name))
+	}
+
+	return shortfall
+}
+
+// shortfallRatio sums the shortfall of each resource as a fraction of the node's allocatable
+// amount, so that nodes of different sizes can be compared.
+func shortfallRatio(node types.NodeInfo, shortfall corev1.ResourceList) float64 {
+	var ratio float64
+	for name, missing := range shortfall {
+		var allocatable resource.Quantity
+		switch name {
+		case corev1.ResourceCPU:
+			allocatable = node.AllocatableCPU
+		case corev1.ResourceMemory:
+			allocatable = node.AllocatableMemory
+		default:
+			allocatable = node.Allocatable[name]
+		}
+		if allocatable.IsZero() {
+			ratio++
+			continue
+		}
+		ratio += float64(missing.MilliValue()) / float64(allocatable.MilliValue())
+	}
+	return ratio
+}
+
+// leastAllocatedScore rates a node by the share of CPU and memory left free after placing
+// the pod, as the scheduler's default LeastAllocated scoring does.
+func leastAllocatedScore(node types.NodeInfo, podCPU, podMemory resource.Quantity) float64 {
+	freeCPU, freeMemory := freeResources(node)
+	share := func(free, required, allocatable resource.Quantity) float64 {
+		if allocatable.IsZero() {
+			return 0
+		}
+		return float64(free.MilliValue()-required.MilliValue()) / float64(allocatable.MilliValue())
+	}
+	return (share(freeCPU, podCPU, node.AllocatableCPU) + share(freeMemory, podMemory, node.AllocatableMemory)) / 2
+}
+
+// formatResourceList renders a resource list in lexical order, e.g. "cpu=500m, memory=1Gi".
+func formatResourceList(list corev1.ResourceList) string {
+	parts := make([]string, 0, len(list))
+	for _, name := range sortedResourceNames(list) {
+		quantity := list[name]
+		parts = append(parts, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestSimulateCollectiveScheduling(t *testing.T) {
+	schedulable := func(pod types.PodInfo) types.AnalysisResult {
+		return types.AnalysisResult{Pod: pod, Category: types.PendingCategoryUnscheduled, IsSchedulable: true}
+	}
+	withHostPort := func(pod types.PodInfo) types.PodInfo {
+		pod.HostPorts = []corev1.ContainerPort{{HostPort: 8080, Protocol: corev1.ProtocolTCP}}
+		return pod
+	}
+	nominated := priorityPod("web", "api-0", 1000, "3", nil)
+	nominated.NominatedNodeName = "node-1"
+	victim := priorityPod("batch", "job-1", 0, "1", nil)
+	victim.Terminating = true
+	largeNode := nodeWithPods("node-1", victim)
+	largeNode.AllocatableCPU = resource.MustParse("4")
+
+	tests := []struct {
+		name              string
+		results           []types.AnalysisResult
+		nodes             []types.NodeInfo
+		expectedUnplaced  []string
+		expectedReason    string
+		expectedShortfall string
+	}{
+		{
+			name: "pods that fit alone but not together",
+			results: []types.AnalysisResult{
+				schedulable(priorityPod("default", "low", 0, "1500m", nil)),
+				schedulable(priorityPod("default", "high-1", 100, "1500m", nil)),
+				schedulable(priorityPod("default", "high-2", 100, "1500m", nil)),
+			},
+			nodes:            []types.NodeInfo{nodeWithPods("node-1"), nodeWithPods("node-2")},
+			expectedUnplaced: []string{"low"},
+			expectedReason: "fits on its own, but not once the 2 pending pod(s) ahead of it in priority order are " +
+				"placed: the closest node node-1 is short by cpu=1",
+			expectedShortfall: "cpu=1",
+		},
+		{
+			name: "pods that fit together",
+			results: []types.AnalysisResult{
+				schedulable(priorityPod("default", "a", 0, "1", nil)),
+				schedulable(priorityPod("default", "b", 0, "1", nil)),
+				schedulable(priorityPod("default", "c", 0, "1", nil)),
+			},
+			nodes: []types.NodeInfo{nodeWithPods("node-1"), nodeWithPods("node-2")},
+		},
+		{
+			name: "nominated pod reserves its node",
+			results: []types.AnalysisResult{
+				schedulable(priorityPod("default", "small", 0, "2", nil)),
+				{Pod: nominated, Category: types.PendingCategoryPreemptionInProgress, IsSchedulable: true},
+			},
+			nodes:            []types.NodeInfo{largeNode},
+			expectedUnplaced: []string{"small"},
+			expectedReason: "fits on its own, but not once the 0 pending pod(s) ahead of it in priority order are " +
+				"placed: the closest node node-1 is short by cpu=1",
+			expectedShortfall: "cpu=1",
+		},
+		{
+			name: "host port taken by another pending pod",
+			results: []types.AnalysisResult{
+				schedulable(withHostPort(priorityPod("default", "proxy-a", 0, "100m", nil))),
+				schedulable(withHostPort(priorityPod("default", "proxy-b", 0, "100m", nil))),
+			},
+			nodes:            []types.NodeInfo{nodeWithPods("node-1")},
+			expectedUnplaced: []string{"proxy-b"},
+			expectedReason: "fits on its own, but no node is left for it once the 1 pending pod(s) ahead of it " +
+				"in priority order are placed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &Analyzer{}
+
+			count := analyzer.simulateCollectiveScheduling(tt.results, tt.nodes, false)
+
+			var unplaced []string
+			for _, result := range tt.results {
+				if result.CollectivelyUnplaced {
+					unplaced = append(unplaced, result.Pod.Name)
+					assert.False(t, result.IsSchedulable)
+					assert.Equal(t, tt.expectedReason, result.Reason)
+					assert.Equal(t, tt.expectedShortfall, formatResourceList(result.Shortfall))
+				}
+			}
+			assert.Equal(t, tt.expectedUnplaced, unplaced)
+			assert.Equal(t, len(tt.expectedUnplaced), count)
+		})
+	}
+}
+
+func TestSimulateCollectiveScheduling_ShortfallAddsUp(t *testing.T) {
+	node := nodeWithPods("node-1")
+	node.AllocatableCPU = resource.MustParse("3")
+	var results []types.AnalysisResult
+	for _, name := range []string{"web-0", "web-1", "web-2"} {
+		results = append(results, types.AnalysisResult{
+			Pod:           priorityPod("default", name, 0, "4", nil),
+			Category:      types.PendingCategoryUnscheduled,
+			IsSchedulable: true,
+		})
+	}
+
+	count := (&Analyzer{}).simulateCollectiveScheduling(results, []types.NodeInfo{node}, false)
+
+	assert.Equal(t, 3, count)
+	var shortfalls []string
+	for _, result := range results {
+		shortfalls = append(shortfalls, formatResourceList(result.Shortfall))
+	}
+	assert.Equal(t, []string{"cpu=1", "cpu=4", "cpu=4"}, shortfalls)
+
+	unplaced, shortfall := collectiveShortfall(results)
+	cpu := shortfall[corev1.ResourceCPU]
+	assert.Equal(t, 3, unplaced)
+	assert.Equal(t, "9", cpu.String())
+}
+
+func TestSimulateCollectiveScheduling_RecomputesDisagreement(t *testing.T) {
+	reported := priorityPod("default", "web-1", 0, "1500m", nil)
+	reported.SchedulerReports = []types.SchedulerReport{{
+		Source:  schedulerReportEvent,
+		Reason:  "FailedScheduling",
+		Message: "0/1 nodes are available: 1 Insufficient cpu.",
+	}}
+	results := []types.AnalysisResult{
+		{Pod: priorityPod("default", "web-0", 100, "1500m", nil), Category: types.PendingCategoryUnscheduled, IsSchedulable: true},
+		{
+			Pod:                   reported,
+			Category:              types.PendingCategoryUnscheduled,
+			IsSchedulable:         true,
+			FittingNodes:          []string{"node-1"},
+			SchedulerDisagreement: "the scheduler reports the pod as unschedulable although the analysis found fitting node(s) node-1",
+		},
+	}
+
+	(&Analyzer{}).simulateCollectiveScheduling(results, []types.NodeInfo{nodeWithPods("node-1")}, false)
+
+	require.True(t, results[1].CollectivelyUnplaced)
+	assert.Empty(t, results[1].SchedulerDisagreement)
+}
+
+func TestPlacementCandidates(t *testing.T) {
+	cordoned := hostnameNode("node-cordoned", "a")
+	cordoned.Unschedulable = true
+	tainted := hostnameNode("node-tainted", "a")
+	tainted.Taints = []corev1.Taint{{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}}
+	other := hostnameNode("node-other", "b")
+	other.Labels["pool"] = "other"
+	nodes := []types.NodeInfo{cordoned, tainted, other, hostnameNode("node-web", "a")}
+	for _, i := range []int{0, 1, 3} {
+		nodes[i].Labels["pool"] = "web"
+	}
+
+	pod := types.PodInfo{Name: "web-0", Namespace: "default", NodeSelector: map[string]string{"pool": "web"}}
+
+	candidates, placement := (&Analyzer{}).placementCandidates(pod, nodes)
+
+	assert.Equal(t, []string{"node-web"}, nodeInfoNames(candidates))
+	assert.Equal(t, []string{"node-cordoned"}, nodeInfoNames(placement.availability.nodes))
+	assert.Equal(t, []string{"node-other"}, nodeInfoNames(placement.selector.nodes))
+	assert.Empty(t, placement.affinity.nodes)
+	assert.Equal(t, []string{"node-tainted"}, nodeInfoNames(placement.taint.nodes))
+	assert.Empty(t, placement.volume.nodes)
+	assert.Len(t, placement.list(nodeExclusion{}), 6)
+}
+
+func TestAnalyzePodSchedulability_CollectiveScheduling(t *testing.T) {
+	pods := []types.PodInfo{
+		priorityPod("default", "web-0", 0, "1500m", nil),
+		priorityPod("default", "web-1", 0, "1500m", nil),
+	}
+
+	mockFetcher := &MockFetcher{}
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return(pods, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return([]types.NodeInfo{nodeWithPods("node-1")}, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return([]types.PodInfo{}, nil)
+
+	results, err := NewAnalyzer(mockFetcher).AnalyzePodSchedulability(context.Background(), "default", false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.True(t, results[0].IsSchedulable)
+	assert.False(t, results[1].IsSchedulable)
+	assert.True(t, results[1].CollectivelyUnplaced)
+	assert.Equal(t, "0/1 nodes are available: 1 Insufficient cpu.", results[1].RejectionSummary)
+	assert.Empty(t, results[1].FittingNodes)
+	mockFetcher.AssertExpectations(t)
+}
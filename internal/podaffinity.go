@@ -0,0 +1,392 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// filterByPodAffinity removes nodes that violate required inter-pod affinity rules. A node is
+// excluded when the pod's requiredDuringSchedulingIgnoredDuringExecution anti-affinity
+// matches a pod running in the same topology domain, when a running pod's required
+// anti-affinity matches the pending pod, or when a required affinity term has no matching pod
+// in the node's topology domain. Topology domains are derived from the labels of all nodes,
+// not only the candidates, because running pods count wherever they are. The running pods are
+// scanned once per call: nodes are grouped by topology value and the existing anti-affinity
+// terms matching the pod are collected up front, so each candidate is checked by lookup.
+//
+// Parameters:
+//   - pod: The pod whose affinity terms are checked
+//   - candidates: Candidate nodes to filter
+//   - allNodes: Every node in the cluster, with their bound pods attached
+//   - namespaceLabels: Namespace labels used to resolve namespaceSelector, keyed by namespace
+//
+// Returns:
+//   - []types.NodeInfo: Nodes on which every required inter-pod rule is satisfied
+//   - nodeExclusion: The excluded nodes, with one detail per node naming the conflict
+func filterByPodAffinity(pod types.PodInfo, candidates, allNodes []types.NodeInfo,
+	namespaceLabels map[string]map[string]string) ([]types.NodeInfo, nodeExclusion) {
+	exclusion := nodeExclusion{constraint: "inter-pod affinity", code: types.ReasonCodePodAffinityConflict}
+	kept := make([]types.NodeInfo, 0, len(candidates))
+
+	affinityTerms := requiredAffinityTerms(pod)
+	antiAffinityTerms := requiredAntiAffinityTerms(pod)
+	affinityBootstrap := len(affinityTerms) > 0 &&
+		!anyPodMatchesTerms(pod, affinityTerms, allNodes, namespaceLabels) &&
+		podMatchesOwnTerms(pod, affinityTerms, namespaceLabels)
+	domains := newTopologyDomains(allNodes)
+	existingTerms := existingAntiAffinityMatching(pod, allNodes, namespaceLabels)
+
+	antiAffinityPods := make(map[string]bool)
+	unmetAffinity := make(map[string]bool)
+
+	for _, node := range candidates {
+		var conflicts, predicates []string
+
+		for _, term := range antiAffinityTerms {
+			for _, i := range domains.nodesSharing(node, term.TopologyKey) {
+				for _, other := range allNodes[i].Pods {
+					if podMatchesTerm(pod.Namespace, term, other, namespaceLabels) {
+						name := podKey(other)
+						antiAffinityPods[name] = true
+						conflicts = append(conflicts, fmt.Sprintf("anti-affinity with pod %s", name))
+					}
+				}
+			}
+		}
+		if len(conflicts) > 0 {
+			predicates = append(predicates, "node(s) didn't match pod anti-affinity rules")
+		}
+		ownConflicts := len(conflicts)
+
+		for _, existing := range existingTerms {
+			if value, ok := node.Labels[existing.topologyKey]; !ok || value != existing.value {
+				continue
+			}
+			name := podKey(existing.pod)
+			antiAffinityPods[name] = true
+			conflicts = append(conflicts, fmt.Sprintf("anti-affinity of pod %s", name))
+		}
+		if len(conflicts) > ownConflicts {
+			predicates = append(predicates, "node(s) didn't satisfy existing pods anti-affinity rules")
+		}
+		antiConflicts := len(conflicts)
+
+		if !affinityBootstrap {
+			for _, term := range affinityTerms {
+				satisfied := false
+				for _, i := range domains.nodesSharing(node, term.TopologyKey) {
+					if anyPodMatchesTerm(pod.Namespace, term, allNodes[i].Pods, namespaceLabels) {
+						satisfied = true
+						break
+					}
+				}
+				if !satisfied {
+					description := formatAffinityTerm(term)
+					unmetAffinity[description] = true
+					conflicts = append(conflicts, fmt.Sprintf("no pod matching %s", description))
+				}
+			}
+		}
+
+		if len(conflicts) > antiConflicts {
+			predicates = append(predicates, "node(s) didn't match pod affinity rules")
+		}
+
+		if len(conflicts) == 0 {
+			kept = append(kept, node)
+			continue
+		}
+
+		exclusion.exclude(node, predicates...)
+		exclusion.details = append(exclusion.details,
+			fmt.Sprintf("%s: %s", node.Name, strings.Join(conflicts, ", ")))
+	}
+
+	var parts, suggestions []string
+	if len(antiAffinityPods) > 0 {
+		podList := strings.Join(sortedKeys(antiAffinityPods), ", ")
+		parts = append(parts, fmt.Sprintf("anti-affinity with pod(s) %s", podList))
+		suggestions = append(suggestions, fmt.Sprintf(
+			"Add nodes in a new topology domain, move or scale down %s, or relax the required pod anti-affinity", podList))
+	}
+	if len(unmetAffinity) > 0 {
+		termList := strings.Join(sortedKeys(unmetAffinity), ", ")
+		parts = append(parts, fmt.Sprintf("no pod matching required affinity (%s)", termList))
+		suggestions = append(suggestions, fmt.Sprintf(
+			"Start a pod matching %s on a suitable node first, or relax the required pod affinity", termList))
+	}
+
+	exclusion.reason = fmt.Sprintf("%s on every eligible node", strings.Join(parts, " or "))
+	exclusion.summary = fmt.Sprintf("%d node(s) violate inter-pod affinity (%s)",
+		len(exclusion.nodes), strings.Join(parts, "; "))
+	exclusion.suggestion = strings.Join(suggestions, "; ")
+
+	return kept, exclusion
+}
+
+// checksPodAffinity reports whether the inter-pod affinity check can reject a node for the
+// pod: the pod has required (anti-)affinity terms, or some pod of the analysis has required
+// anti-affinity that may select it.
+func (a *Analyzer) checksPodAffinity(pod types.PodInfo) bool {
+	return !a.noRequiredAntiAffinity || len(requiredAffinityTerms(pod)) > 0 || len(requiredAntiAffinityTerms(pod)) > 0
+}
+
+// podAffinityFilter returns the inter-pod affinity filter against allNodes. When the check
+// cannot reject any node for the pod, every candidate is kept without scanning the cluster.
+func (a *Analyzer) podAffinityFilter(
+	allNodes []types.NodeInfo) func(types.PodInfo, []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+	return func(pod types.PodInfo, candidates []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+		if !a.checksPodAffinity(pod) {
+			return candidates, nodeExclusion{}
+		}
+		return filterByPodAffinity(pod, candidates, allNodes, a.namespaceLabels)
+	}
+}
+
+// requiredAffinityTerms returns the pod's requiredDuringSchedulingIgnoredDuringExecution
+// pod affinity terms.
+func requiredAffinityTerms(pod types.PodInfo) []corev1.PodAffinityTerm {
+	if pod.PodAffinity == nil {
+		return nil
+	}
+	return pod.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+}
+
+// requiredAntiAffinityTerms returns the pod's requiredDuringSchedulingIgnoredDuringExecution
+// pod anti-affinity terms.
+func requiredAntiAffinityTerms(pod types.PodInfo) []corev1.PodAffinityTerm {
+	if pod.PodAntiAffinity == nil {
+		return nil
+	}
+	return pod.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+}
+
+// podMatchesTerm reports whether a pod is selected by an affinity term declared by a pod in
+// ownerNamespace. An empty namespaces list together with a nil namespaceSelector means the
+// owner's own namespace; otherwise the listed namespaces and the namespaces whose labels
+// match the namespaceSelector are used.
+func podMatchesTerm(ownerNamespace string, term corev1.PodAffinityTerm, pod types.PodInfo,
+	namespaceLabels map[string]map[string]string) bool {
+	if !termCoversNamespace(ownerNamespace, term, pod.Namespace, namespaceLabels) {
+		return false
+	}
+	if term.LabelSelector == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(pod.Labels))
+}
+
+// termCoversNamespace reports whether an affinity term applies to pods in the given namespace.
+func termCoversNamespace(ownerNamespace string, term corev1.PodAffinityTerm, namespace string,
+	namespaceLabels map[string]map[string]string) bool {
+	if len(term.Namespaces) == 0 && term.NamespaceSelector == nil {
+		return namespace == ownerNamespace
+	}
+	if containsString(term.Namespaces, namespace) {
+		return true
+	}
+	if term.NamespaceSelector == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(term.NamespaceSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(namespaceLabels[namespace]))
+}
+
+// anyPodMatchesTerms reports whether any running pod matches at least one of the terms.
+func anyPodMatchesTerms(pod types.PodInfo, terms []corev1.PodAffinityTerm, allNodes []types.NodeInfo,
+	namespaceLabels map[string]map[string]string) bool {
+	for _, node := range allNodes {
+		for _, term := range terms {
+			if anyPodMatchesTerm(pod.Namespace, term, node.Pods, namespaceLabels) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// anyPodMatchesTerm reports whether any of the pods is selected by a term declared by a pod
+// in ownerNamespace.
+func anyPodMatchesTerm(ownerNamespace string, term corev1.PodAffinityTerm, pods []types.PodInfo,
+	namespaceLabels map[string]map[string]string) bool {
+	for _, other := range pods {
+		if podMatchesTerm(ownerNamespace, term, other, namespaceLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+// existingAntiAffinity is a required anti-affinity term of a running pod that selects the
+// pending pod, with the topology value of the node the running pod is bound to.
+type existingAntiAffinity struct {
+	pod         types.PodInfo
+	topologyKey string
+	value       string
+}
+
+// existingAntiAffinityMatching collects the required anti-affinity terms of running pods that
+// select the pod. Terms whose topology key the running pod's node lacks cover no domain and are
+// left out.
+func existingAntiAffinityMatching(pod types.PodInfo, allNodes []types.NodeInfo,
+	namespaceLabels map[string]map[string]string) []existingAntiAffinity {
+	var matching []existingAntiAffinity
+	for _, node := range allNodes {
+		for _, other := range node.Pods {
+			for _, term := range requiredAntiAffinityTerms(other) {
+				value, ok := node.Labels[term.TopologyKey]
+				if !ok || !podMatchesTerm(other.Namespace, term, pod, namespaceLabels) {
+					continue
+				}
+				matching = append(matching, existingAntiAffinity{pod: other, topologyKey: term.TopologyKey, value: value})
+			}
+		}
+	}
+	return matching
+}
+
+// podMatchesOwnTerms reports whether the pod matches all of its own affinity terms. Like the
+// scheduler, such a pod may be placed even when no matching pod runs yet, so the first replica
+// of a group with self-affinity is not blocked forever.
+func podMatchesOwnTerms(pod types.PodInfo, terms []corev1.PodAffinityTerm,
+	namespaceLabels map[string]map[string]string) bool {
+	for _, term := range terms {
+		if !podMatchesTerm(pod.Namespace, term, pod, namespaceLabels) {
+			return false
+		}
+	}
+	return true
+}
+
+// topologyDomains groups the indexes of the nodes by their value for a topology key, built
+// once per key, so the nodes sharing a domain are found without scanning every node.
+type topologyDomains struct {
+	nodes []types.NodeInfo
+	byKey map[string]map[string][]int
+}
+
+// newTopologyDomains returns an empty grouping of the nodes.
+func newTopologyDomains(nodes []types.NodeInfo) *topologyDomains {
+	return &topologyDomains{nodes: nodes, byKey: make(map[string]map[string][]int)}
+}
+
+// nodesSharing returns the indexes of the nodes that carry the node's value for the topology
+// key, in node order. A node without the key belongs to no domain and shares it with no node.
+func (d *topologyDomains) nodesSharing(node types.NodeInfo, topologyKey string) []int {
+	value, ok := node.Labels[topologyKey]
+	if !ok {
+		return nil
+	}
+
+	domains, ok := d.byKey[topologyKey]
+	if !ok {
+		domains = make(map[string][]int)
+		for i, other := range d.nodes {
+			if otherValue, ok := other.Labels[topologyKey]; ok {
+				domains[otherValue] = append(domains[otherValue], i)
+			}
+		}
+		d.byKey[topologyKey] = domains
+	}
+	return domains[value]
+}
+
+// hasRequiredAntiAffinity reports whether any of the pods has a required anti-affinity term.
+func hasRequiredAntiAffinity(pods []types.PodInfo) bool {
+	for _, pod := range pods {
+		if len(requiredAntiAffinityTerms(pod)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeByName returns the node with the given name, or nil if it is not in the list.
+func nodeByName(nodes []types.NodeInfo, name string) *types.NodeInfo {
+	for i := range nodes {
+		if nodes[i].Name == name {
+			return &nodes[i]
+		}
+	}
+	return nil
+}
+
+// podKey renders a pod as namespace/name.
+func podKey(pod types.PodInfo) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+// formatAffinityTerm renders an affinity term as "<selector> in <topologyKey>".
+func formatAffinityTerm(term corev1.PodAffinityTerm) string {
+	return fmt.Sprintf("%s in %s", metav1.FormatLabelSelector(term.LabelSelector), term.TopologyKey)
+}
+
+// sortedKeys returns the keys of a string set in lexical order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// withoutNamespaceSelectorTerms returns the pods with every required inter-pod term that
+// relies on a namespaceSelector removed, for when namespace labels cannot be fetched. The
+// other terms are still evaluated; the pods passed in are not modified.
+func withoutNamespaceSelectorTerms(pods []types.PodInfo) []types.PodInfo {
+	withoutSelector := func(terms []corev1.PodAffinityTerm) []corev1.PodAffinityTerm {
+		var kept []corev1.PodAffinityTerm
+		for _, term := range terms {
+			if term.NamespaceSelector == nil {
+				kept = append(kept, term)
+			}
+		}
+		return kept
+	}
+
+	stripped := make([]types.PodInfo, len(pods))
+	for i, pod := range pods {
+		if pod.PodAffinity != nil {
+			affinity := *pod.PodAffinity
+			affinity.RequiredDuringSchedulingIgnoredDuringExecution =
+				withoutSelector(affinity.RequiredDuringSchedulingIgnoredDuringExecution)
+			pod.PodAffinity = &affinity
+		}
+		if pod.PodAntiAffinity != nil {
+			antiAffinity := *pod.PodAntiAffinity
+			antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution =
+				withoutSelector(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+			pod.PodAntiAffinity = &antiAffinity
+		}
+		stripped[i] = pod
+	}
+	return stripped
+}
+
+// usesNamespaceSelector reports whether any required inter-pod term of the pods relies on
+// a namespaceSelector, which means namespace labels have to be fetched.
+func usesNamespaceSelector(pods []types.PodInfo) bool {
+	for _, pod := range pods {
+		terms := append(append([]corev1.PodAffinityTerm{}, requiredAffinityTerms(pod)...), requiredAntiAffinityTerms(pod)...)
+		for _, term := range terms {
+			if term.NamespaceSelector != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,308 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func hostnameNode(name, zone string, pods ...types.PodInfo) types.NodeInfo {
+	return types.NodeInfo{
+		Name:              name,
+		AllocatableCPU:    resource.MustParse("4"),
+		AllocatableMemory: resource.MustParse("8Gi"),
+		Labels: map[string]string{
+			"kubernetes.io/hostname":      name,
+			"topology.kubernetes.io/zone": zone,
+		},
+		Pods: pods,
+	}
+}
+
+func labeledPod(namespace, name string, podLabels map[string]string) types.PodInfo {
+	return types.PodInfo{Name: name, Namespace: namespace, Labels: podLabels}
+}
+
+func affinityTerm(topologyKey string, matchLabels map[string]string) corev1.PodAffinityTerm {
+	return corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: matchLabels},
+		TopologyKey:   topologyKey,
+	}
+}
+
+func TestFilterByPodAffinity(t *testing.T) {
+	web := map[string]string{"app": "web"}
+
+	t.Run("hostname anti-affinity on a full pool", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			hostnameNode("node1", "a", labeledPod("default", "web-0", web)),
+			hostnameNode("node2", "a", labeledPod("default", "web-1", web)),
+			hostnameNode("node3", "b", labeledPod("default", "web-2", web)),
+		}
+		pod := labeledPod("default", "web-3", web)
+		pod.PodAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				affinityTerm("kubernetes.io/hostname", web),
+			},
+		}
+
+		kept, exclusion := filterByPodAffinity(pod, nodes, nodes, nil)
+
+		assert.Empty(t, kept)
+		assert.Equal(t, "anti-affinity with pod(s) default/web-0, default/web-1, default/web-2 on every eligible node",
+			exclusion.reason)
+		assert.Equal(t, []string{
+			"node1: anti-affinity with pod default/web-0",
+			"node2: anti-affinity with pod default/web-1",
+			"node3: anti-affinity with pod default/web-2",
+		}, exclusion.details)
+		assert.Equal(t, []string{"node(s) didn't match pod anti-affinity rules"}, exclusion.predicates["node1"])
+	})
+
+	t.Run("zone anti-affinity keeps other zones", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			hostnameNode("node1", "a", labeledPod("default", "web-0", web)),
+			hostnameNode("node2", "a"),
+			hostnameNode("node3", "b"),
+		}
+		pod := labeledPod("default", "web-1", web)
+		pod.PodAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				affinityTerm("topology.kubernetes.io/zone", web),
+			},
+		}
+
+		kept, _ := filterByPodAffinity(pod, nodes, nodes, nil)
+
+		assert.Equal(t, []string{"node3"}, nodeInfoNames(kept))
+	})
+
+	t.Run("anti-affinity of a running pod", func(t *testing.T) {
+		exclusive := labeledPod("default", "exclusive", map[string]string{"app": "exclusive"})
+		exclusive.NodeName = "node1"
+		exclusive.PodAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				affinityTerm("kubernetes.io/hostname", web),
+			},
+		}
+		nodes := []types.NodeInfo{
+			hostnameNode("node1", "a", exclusive),
+			hostnameNode("node2", "a"),
+		}
+
+		kept, exclusion := filterByPodAffinity(labeledPod("default", "web-0", web), nodes, nodes, nil)
+
+		assert.Equal(t, []string{"node2"}, nodeInfoNames(kept))
+		assert.Equal(t, []string{"node1: anti-affinity of pod default/exclusive"}, exclusion.details)
+		assert.Equal(t, []string{"node(s) didn't satisfy existing pods anti-affinity rules"}, exclusion.predicates["node1"])
+	})
+
+	t.Run("zone anti-affinity of a running pod", func(t *testing.T) {
+		exclusive := labeledPod("default", "exclusive", map[string]string{"app": "exclusive"})
+		exclusive.PodAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				affinityTerm("topology.kubernetes.io/zone", web),
+			},
+		}
+		nodes := []types.NodeInfo{
+			hostnameNode("node1", "a", exclusive),
+			hostnameNode("node2", "a"),
+			hostnameNode("node3", "b"),
+		}
+
+		kept, exclusion := filterByPodAffinity(labeledPod("default", "web-0", web), nodes[1:], nodes, nil)
+
+		assert.Equal(t, []string{"node3"}, nodeInfoNames(kept))
+		assert.Equal(t, []string{"node2: anti-affinity of pod default/exclusive"}, exclusion.details)
+	})
+
+	t.Run("anti-affinity ignores other namespaces", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			hostnameNode("node1", "a", labeledPod("other", "web-0", web)),
+		}
+		pod := labeledPod("default", "web-1", web)
+		pod.PodAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				affinityTerm("kubernetes.io/hostname", web),
+			},
+		}
+
+		kept, _ := filterByPodAffinity(pod, nodes, nodes, nil)
+
+		assert.Equal(t, []string{"node1"}, nodeInfoNames(kept))
+	})
+
+	t.Run("namespaceSelector widens anti-affinity", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			hostnameNode("node1", "a", labeledPod("team-b", "web-0", web)),
+			hostnameNode("node2", "a", labeledPod("sandbox", "web-0", web)),
+		}
+		term := affinityTerm("kubernetes.io/hostname", web)
+		term.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "prod"}}
+		pod := labeledPod("team-a", "web-1", web)
+		pod.PodAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{term},
+		}
+		namespaceLabels := map[string]map[string]string{
+			"team-b":  {"tier": "prod"},
+			"sandbox": {"tier": "dev"},
+		}
+
+		kept, _ := filterByPodAffinity(pod, nodes, nodes, namespaceLabels)
+
+		assert.Equal(t, []string{"node2"}, nodeInfoNames(kept))
+	})
+
+	t.Run("required affinity", func(t *testing.T) {
+		cache := map[string]string{"app": "cache"}
+		nodes := []types.NodeInfo{
+			hostnameNode("node1", "a", labeledPod("default", "cache-0", cache)),
+			hostnameNode("node2", "a"),
+		}
+		pod := labeledPod("default", "web-0", web)
+		pod.PodAffinity = &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				affinityTerm("kubernetes.io/hostname", cache),
+			},
+		}
+
+		kept, exclusion := filterByPodAffinity(pod, nodes, nodes, nil)
+
+		assert.Equal(t, []string{"node1"}, nodeInfoNames(kept))
+		assert.Equal(t, []string{"node2: no pod matching app=cache in kubernetes.io/hostname"}, exclusion.details)
+
+		kept, exclusion = filterByPodAffinity(pod, nodes[1:], nodes[1:], nil)
+
+		assert.Empty(t, kept)
+		assert.Equal(t, "no pod matching required affinity (app=cache in kubernetes.io/hostname) on every eligible node",
+			exclusion.reason)
+	})
+
+	t.Run("self affinity of the first replica", func(t *testing.T) {
+		nodes := []types.NodeInfo{hostnameNode("node1", "a"), hostnameNode("node2", "b")}
+		pod := labeledPod("default", "web-0", web)
+		pod.PodAffinity = &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				affinityTerm("topology.kubernetes.io/zone", web),
+			},
+		}
+
+		kept, _ := filterByPodAffinity(pod, nodes, nodes, nil)
+
+		assert.Equal(t, []string{"node1", "node2"}, nodeInfoNames(kept))
+	})
+}
+
+func TestAnalyzePodSchedulability_PodAntiAffinity(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	term := affinityTerm("kubernetes.io/hostname", web)
+	term.NamespaceSelector = &metav1.LabelSelector{}
+
+	pending := labeledPod("default", "web-2", web)
+	pending.RequestsCPU = resource.MustParse("1")
+	pending.RequestsMemory = resource.MustParse("1Gi")
+	pending.PodAntiAffinity = &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{term},
+	}
+
+	running := []types.PodInfo{labeledPod("default", "web-0", web), labeledPod("other", "web-1", web)}
+	running[0].NodeName = "node1"
+	running[1].NodeName = "node2"
+
+	nodes := []types.NodeInfo{hostnameNode("node1", "a"), hostnameNode("node2", "a")}
+
+	mockFetcher := &MockFetcher{}
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return([]types.PodInfo{pending}, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return(nodes, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return(running, nil)
+	mockFetcher.On("FetchNamespaceLabels", mock.Anything).Return(map[string]map[string]string{
+		"default": {}, "other": {},
+	}, nil)
+
+	results, err := NewAnalyzer(mockFetcher).AnalyzePodSchedulability(context.Background(), "default", false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].IsSchedulable)
+	assert.Equal(t, "anti-affinity with pod(s) default/web-0, other/web-1 on every eligible node", results[0].Reason)
+	assert.Len(t, results[0].PodAffinityConflicts, 2)
+	mockFetcher.AssertExpectations(t)
+}
+
+func TestAnalyzePodSchedulability_NamespaceLabelsUnavailable(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	selectorTerm := affinityTerm("kubernetes.io/hostname", web)
+	selectorTerm.NamespaceSelector = &metav1.LabelSelector{}
+
+	pending := labeledPod("default", "web-2", web)
+	pending.RequestsCPU = resource.MustParse("1")
+	pending.RequestsMemory = resource.MustParse("1Gi")
+	pending.PodAntiAffinity = &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+			selectorTerm,
+			affinityTerm("kubernetes.io/hostname", web),
+		},
+	}
+
+	running := []types.PodInfo{labeledPod("default", "web-0", web), labeledPod("other", "web-1", web)}
+	running[0].NodeName = "node1"
+	running[1].NodeName = "node2"
+
+	nodes := []types.NodeInfo{hostnameNode("node1", "a"), hostnameNode("node2", "a")}
+
+	mockFetcher := &MockFetcher{}
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return([]types.PodInfo{pending}, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return(nodes, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return(running, nil)
+	mockFetcher.On("FetchNamespaceLabels", mock.Anything).Return(map[string]map[string]string(nil),
+		errors.New("namespaces is forbidden"))
+
+	results, err := NewAnalyzer(mockFetcher).AnalyzePodSchedulability(context.Background(), "default", false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].IsSchedulable)
+	assert.Equal(t, []string{"node2"}, results[0].FittingNodes)
+	assert.Len(t, pending.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 2)
+	mockFetcher.AssertExpectations(t)
+}
+
+func TestChecksPodAffinity(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	withAffinity := labeledPod("default", "web-0", web)
+	withAffinity.PodAffinity = &corev1.PodAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{affinityTerm("kubernetes.io/hostname", web)},
+	}
+	withAntiAffinity := labeledPod("default", "web-0", web)
+	withAntiAffinity.PodAntiAffinity = &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{affinityTerm("kubernetes.io/hostname", web)},
+	}
+
+	tests := []struct {
+		name                   string
+		pod                    types.PodInfo
+		noRequiredAntiAffinity bool
+		expected               bool
+	}{
+		{name: "no terms anywhere", pod: labeledPod("default", "web-0", web), noRequiredAntiAffinity: true},
+		{name: "running pods with anti-affinity", pod: labeledPod("default", "web-0", web), expected: true},
+		{name: "own affinity", pod: withAffinity, noRequiredAntiAffinity: true, expected: true},
+		{name: "own anti-affinity", pod: withAntiAffinity, noRequiredAntiAffinity: true, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &Analyzer{noRequiredAntiAffinity: tt.noRequiredAntiAffinity}
+
+			assert.Equal(t, tt.expected, analyzer.checksPodAffinity(tt.pod))
+		})
+	}
+}
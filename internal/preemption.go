@@ -0,0 +1,303 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// preemptionOption is a node on which preemption would make room, with the pods that
+// would have to be evicted.
+type preemptionOption struct {
+	node          string
+	victims       []types.PodInfo
+	pdbViolations int
+}
+
+// simulatePreemption checks whether evicting lower-priority pods would let the pod fit,
+// following the scheduler's default preemption. On every node, all lower-priority pods are
+// removed first; if the pod then fits, victims are reprieved again from the highest priority
+// down, starting with those protected by a PodDisruptionBudget, as long as the pod still fits.
+// Among the resulting nodes the one with the fewest budget violations, then the lowest
+// highest victim priority, the lowest priority sum and the fewest victims is chosen. When the
+// budgets could not be fetched, they are left out and the candidate is marked as such.
+//
+// Parameters:
+//   - pod: The pod that does not fit
+//   - candidates: Nodes whose failures eviction cannot resolve (selector, affinity, taints,
+//     availability, volume topology) are already removed
+//   - allNodes: Every node in the cluster, with their bound pods attached
+//   - podCPU, podMemory: The pod's CPU and memory requirements
+//   - extended: The pod's extended resource requirements
+//
+// Returns:
+//   - *types.PreemptionCandidate: The chosen node and victims, or nil if preemption cannot help
+func (a *Analyzer) simulatePreemption(pod types.PodInfo, candidates, allNodes []types.NodeInfo,
+	podCPU, podMemory resource.Quantity, extended corev1.ResourceList) *types.PreemptionCandidate {
+	if pod.PreemptionPolicy == string(corev1.PreemptNever) {
+		return nil
+	}
+
+	var best *preemptionOption
+	for _, node := range candidates {
+		option := a.preemptionOnNode(pod, node, allNodes, podCPU, podMemory, extended)
+		if option != nil && (best == nil || betterPreemption(*option, *best)) {
+			best = option
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+
+	victims := make([]string, 0, len(best.victims))
+	for _, victim := range best.victims {
+		victims = append(victims, fmt.Sprintf("%s (priority %d)", podKey(victim), victim.Priority))
+	}
+
+	return &types.PreemptionCandidate{
+		NodeName:             best.node,
+		Victims:              victims,
+		PDBViolations:        best.pdbViolations,
+		PDBViolationsUnknown: a.disruptionBudgetsUnknown,
+	}
+}
+
+// preemptionOnNode selects the victims on a single node, or returns nil if evicting every
+// lower-priority pod on it would still not make room.
+func (a *Analyzer) preemptionOnNode(pod types.PodInfo, node types.NodeInfo, allNodes []types.NodeInfo,
+	podCPU, podMemory resource.Quantity, extended corev1.ResourceList) *preemptionOption {
+	var potential []types.PodInfo
+	for _, other := range node.Pods {
+		if other.Priority < pod.Priority {
+			potential = append(potential, other)
+		}
+	}
+	if len(potential) == 0 {
+		return nil
+	}
+
+	remaining := withoutPods(node, potential)
+	if !a.podFitsNode(pod, remaining, allNodes, podCPU, podMemory, extended) {
+		return nil
+	}
+
+	sort.SliceStable(potential, func(i, j int) bool { return potential[i].Priority > potential[j].Priority })
+	violating, nonViolating := splitByDisruptionBudgets(potential, a.disruptionBudgets)
+
+	option := &preemptionOption{node: node.Name}
+	reprieve := func(victim types.PodInfo, violatesBudget bool) {
+		restored := withPod(remaining, victim)
+		if a.podFitsNode(pod, restored, allNodes, podCPU, podMemory, extended) {
+			remaining = restored
+			return
+		}
+		option.victims = append(option.victims, victim)
+		if violatesBudget {
+			option.pdbViolations++
+		}
+	}
+	for _, victim := range violating {
+		reprieve(victim, true)
+	}
+	for _, victim := range nonViolating {
+		reprieve(victim, false)
+	}
+
+	return option
+}
+
+// podFitsNode reports whether the pod fits a node on every check that evicting pods can
+// change: volume attach limits, host ports, inter-pod affinity, topology spread, pod slots
+// and free resources. The node list is only copied with the changed node when the inter-pod
+// affinity or topology spread check has to look at the other nodes.
+func (a *Analyzer) podFitsNode(pod types.PodInfo, node types.NodeInfo, allNodes []types.NodeInfo,
+	podCPU, podMemory resource.Quantity, extended corev1.ResourceList) bool {
+	if a.checksPodAffinity(pod) || len(hardSpreadConstraints(pod)) > 0 {
+		allNodes = replaceNode(allNodes, node)
+	}
+
+	kept, _ := a.runFilter(pod, nodeVolumeLimitsPlugin, []types.NodeInfo{node}, filterByVolumeLimits)
+	kept, _ = a.runFilter(pod, nodePortsPlugin, kept, filterByHostPorts)
+	kept, _ = a.runFilter(pod, interPodAffinityPlugin, kept, a.podAffinityFilter(allNodes))
+	kept, _ = a.runFilter(pod, podTopologySpreadPlugin, kept,
+		func(pod types.PodInfo, kept []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+			return filterByTopologySpread(pod, kept, allNodes)
+		})
+	kept, _ = a.runFilter(pod, nodeResourcesFitPlugin, kept,
+		func(_ types.PodInfo, kept []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+			return filterByPodCapacity(kept)
+		})
+
+	return len(kept) == 1 && len(insufficientResources(node, podCPU, podMemory, extended)) == 0
+}
+
+// splitByDisruptionBudgets divides the victims, in order, into those whose eviction would
+// exceed the disruptions allowed by a PodDisruptionBudget and those that may be evicted.
+func splitByDisruptionBudgets(victims []types.PodInfo,
+	budgets []types.PodDisruptionBudgetInfo) ([]types.PodInfo, []types.PodInfo) {
+	allowed := make([]int32, len(budgets))
+	for i, budget := range budgets {
+		allowed[i] = budget.DisruptionsAllowed
+	}
+
+	var violating, nonViolating []types.PodInfo
+	for _, victim := range victims {
+		violates := false
+		for i, budget := range budgets {
+			if budget.Namespace != victim.Namespace || !disruptionBudgetMatches(budget, victim) {
+				continue
+			}
+			allowed[i]--
+			if allowed[i] < 0 {
+				violates = true
+			}
+		}
+
+		if violates {
+			violating = append(violating, victim)
+		} else {
+			nonViolating = append(nonViolating, victim)
+		}
+	}
+
+	return violating, nonViolating
+}
+
+// disruptionBudgetMatches reports whether a budget's selector selects the pod. A nil or
+// empty selector matches nothing, as for the scheduler.
+func disruptionBudgetMatches(budget types.PodDisruptionBudgetInfo, pod types.PodInfo) bool {
+	if budget.Selector == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(budget.Selector)
+	if err != nil || selector.Empty() {
+		return false
+	}
+	return selector.Matches(labels.Set(pod.Labels))
+}
+
+// betterPreemption reports whether option a is preferable to option b.
+func betterPreemption(a, b preemptionOption) bool {
+	if a.pdbViolations != b.pdbViolations {
+		return a.pdbViolations < b.pdbViolations
+	}
+	if highestA, highestB := highestPriority(a.victims), highestPriority(b.victims); highestA != highestB {
+		return highestA < highestB
+	}
+	if sumA, sumB := prioritySum(a.victims), prioritySum(b.victims); sumA != sumB {
+		return sumA < sumB
+	}
+	return len(a.victims) < len(b.victims)
+}
+
+func highestPriority(pods []types.PodInfo) int32 {
+	var highest int32
+	for i, pod := range pods {
+		if i == 0 || pod.Priority > highest {
+			highest = pod.Priority
+		}
+	}
+	return highest
+}
+
+func prioritySum(pods []types.PodInfo) int64 {
+	var sum int64
+	for _, pod := range pods {
+		sum += int64(pod.Priority)
+	}
+	return sum
+}
+
+// withoutPod returns a copy of the node with the pod and its requests removed.
+func withoutPod(node types.NodeInfo, pod types.PodInfo) types.NodeInfo {
+	return withoutPods(node, []types.PodInfo{pod})
+}
+
+// withoutPods returns a copy of the node with the pods and their requests removed, copying
+// the node's pod list once.
+func withoutPods(node types.NodeInfo, pods []types.PodInfo) types.NodeInfo {
+	updated := copyNodeUsage(node)
+	removed := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		updated.RequestedCPU.Sub(pod.RequestsCPU)
+		updated.RequestedMemory.Sub(pod.RequestsMemory)
+		for name, quantity := range pod.Requests {
+			requested := updated.Requested[name]
+			requested.Sub(quantity)
+			updated.Requested[name] = requested
+		}
+		updated.PodCount--
+		removed[podKey(pod)] = true
+	}
+
+	updated.Pods = make([]types.PodInfo, 0, len(node.Pods))
+	for _, other := range node.Pods {
+		if !removed[podKey(other)] {
+			updated.Pods = append(updated.Pods, other)
+		}
+	}
+	return updated
+}
+
+// withPod returns a copy of the node with the pod and its requests added.
+func withPod(node types.NodeInfo, pod types.PodInfo) types.NodeInfo {
+	updated := copyNodeUsage(node)
+	updated.RequestedCPU.Add(pod.RequestsCPU)
+	updated.RequestedMemory.Add(pod.RequestsMemory)
+	for name, quantity := range pod.Requests {
+		requested := updated.Requested[name]
+		requested.Add(quantity)
+		updated.Requested[name] = requested
+	}
+	updated.PodCount++
+	updated.Pods = append(append([]types.PodInfo{}, node.Pods...), pod)
+	return updated
+}
+
+// copyNodeUsage copies the node with its usage fields deep-copied, so they can be changed
+// without affecting the original.
+func copyNodeUsage(node types.NodeInfo) types.NodeInfo {
+	updated := node
+	updated.RequestedCPU = node.RequestedCPU.DeepCopy()
+	updated.RequestedMemory = node.RequestedMemory.DeepCopy()
+	updated.Requested = node.Requested.DeepCopy()
+	if updated.Requested == nil {
+		updated.Requested = make(corev1.ResourceList)
+	}
+	return updated
+}
+
+// replaceNode returns a copy of the node list with the node of the same name replaced.
+func replaceNode(nodes []types.NodeInfo, node types.NodeInfo) []types.NodeInfo {
+	replaced := make([]types.NodeInfo, len(nodes))
+	for i, existing := range nodes {
+		if existing.Name == node.Name {
+			replaced[i] = node
+		} else {
+			replaced[i] = existing
+		}
+	}
+	return replaced
+}
+
+// mayPreempt reports whether any pending pod is allowed to preempt and has a higher
+// priority than some running pod, so that preemption needs to be simulated.
+func mayPreempt(pending, scheduled []types.PodInfo) bool {
+	for _, pod := range pending {
+		if pod.PreemptionPolicy == string(corev1.PreemptNever) {
+			continue
+		}
+		for _, other := range scheduled {
+			if other.Priority < pod.Priority {
+				return true
+			}
+		}
+	}
+	return false
+}
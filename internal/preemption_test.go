@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func priorityPod(namespace, name string, priority int32, cpu string, podLabels map[string]string) types.PodInfo {
+	pod := labeledPod(namespace, name, podLabels)
+	pod.Priority = priority
+	pod.RequestsCPU = resource.MustParse(cpu)
+	pod.RequestsMemory = resource.MustParse("256Mi")
+	return pod
+}
+
+// nodeWithPods builds a 2-CPU node whose usage reflects the given running pods.
+func nodeWithPods(name string, pods ...types.PodInfo) types.NodeInfo {
+	node := hostnameNode(name, "a")
+	node.AllocatableCPU = resource.MustParse("2")
+	for _, pod := range pods {
+		pod.NodeName = name
+		node = withPod(node, pod)
+	}
+	return node
+}
+
+func TestSimulatePreemption(t *testing.T) {
+	batch := map[string]string{"app": "batch"}
+
+	t.Run("evicts the fewest lower-priority pods", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			nodeWithPods("node-1",
+				priorityPod("batch", "job-0", 0, "1", batch),
+				priorityPod("batch", "job-1", 0, "1", batch)),
+		}
+		pod := priorityPod("default", "api-0", 1000, "1", nil)
+
+		preemption := (&Analyzer{}).simulatePreemption(pod, nodes, nodes, pod.RequestsCPU, pod.RequestsMemory, nil)
+
+		require.NotNil(t, preemption)
+		assert.Equal(t, "node-1", preemption.NodeName)
+		assert.Len(t, preemption.Victims, 1)
+		assert.Equal(t, 0, preemption.PDBViolations)
+	})
+
+	t.Run("equal or higher priority pods are never victims", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			nodeWithPods("node-1",
+				priorityPod("default", "api-1", 1000, "1", nil),
+				priorityPod("default", "api-2", 2000, "1", nil)),
+		}
+		pod := priorityPod("default", "api-0", 1000, "1", nil)
+
+		assert.Nil(t, (&Analyzer{}).simulatePreemption(pod, nodes, nodes, pod.RequestsCPU, pod.RequestsMemory, nil))
+	})
+
+	t.Run("preemptionPolicy Never", func(t *testing.T) {
+		nodes := []types.NodeInfo{nodeWithPods("node-1", priorityPod("batch", "job-0", 0, "2", batch))}
+		pod := priorityPod("default", "api-0", 1000, "1", nil)
+		pod.PreemptionPolicy = string(corev1.PreemptNever)
+
+		assert.Nil(t, (&Analyzer{}).simulatePreemption(pod, nodes, nodes, pod.RequestsCPU, pod.RequestsMemory, nil))
+	})
+
+	t.Run("prefers the node without budget violations", func(t *testing.T) {
+		protected := map[string]string{"app": "protected"}
+		nodes := []types.NodeInfo{
+			nodeWithPods("node-1", priorityPod("batch", "guarded-0", 0, "2", protected)),
+			nodeWithPods("node-2", priorityPod("batch", "job-0", 100, "2", batch)),
+		}
+		analyzer := &Analyzer{disruptionBudgets: []types.PodDisruptionBudgetInfo{{
+			Name:      "guarded",
+			Namespace: "batch",
+			Selector:  &metav1.LabelSelector{MatchLabels: protected},
+		}}}
+		pod := priorityPod("default", "api-0", 1000, "1", nil)
+
+		preemption := analyzer.simulatePreemption(pod, nodes, nodes, pod.RequestsCPU, pod.RequestsMemory, nil)
+
+		require.NotNil(t, preemption)
+		assert.Equal(t, "node-2", preemption.NodeName)
+		assert.Equal(t, []string{"batch/job-0 (priority 100)"}, preemption.Victims)
+		assert.Equal(t, 0, preemption.PDBViolations)
+	})
+
+	t.Run("budget violation reported when unavoidable", func(t *testing.T) {
+		protected := map[string]string{"app": "protected"}
+		nodes := []types.NodeInfo{nodeWithPods("node-1", priorityPod("batch", "guarded-0", 0, "2", protected))}
+		analyzer := &Analyzer{disruptionBudgets: []types.PodDisruptionBudgetInfo{{
+			Name:      "guarded",
+			Namespace: "batch",
+			Selector:  &metav1.LabelSelector{MatchLabels: protected},
+		}}}
+		pod := priorityPod("default", "api-0", 1000, "1", nil)
+
+		preemption := analyzer.simulatePreemption(pod, nodes, nodes, pod.RequestsCPU, pod.RequestsMemory, nil)
+
+		require.NotNil(t, preemption)
+		assert.Equal(t, 1, preemption.PDBViolations)
+	})
+}
+
+func TestSplitByDisruptionBudgets(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	victims := []types.PodInfo{
+		labeledPod("default", "web-0", web),
+		labeledPod("default", "web-1", web),
+		labeledPod("other", "web-0", web),
+	}
+	budgets := []types.PodDisruptionBudgetInfo{
+		{Name: "web", Namespace: "default", Selector: &metav1.LabelSelector{MatchLabels: web}, DisruptionsAllowed: 1},
+		{Name: "everything", Namespace: "other", Selector: &metav1.LabelSelector{}},
+	}
+
+	violating, nonViolating := splitByDisruptionBudgets(victims, budgets)
+
+	assert.Equal(t, []string{"web-1"}, podNames(violating))
+	assert.Equal(t, []string{"web-0", "web-0"}, podNames(nonViolating))
+}
+
+func podNames(pods []types.PodInfo) []string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestAnalyzePodSchedulability_Preemption(t *testing.T) {
+	running := []types.PodInfo{priorityPod("batch", "job-0", 0, "2", nil)}
+	running[0].NodeName = "node-1"
+
+	pending := priorityPod("default", "api-0", 1000, "1", nil)
+
+	node := hostnameNode("node-1", "a")
+	node.AllocatableCPU = resource.MustParse("2")
+
+	mockFetcher := &MockFetcher{}
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return([]types.PodInfo{pending}, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return([]types.NodeInfo{node}, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return(running, nil)
+	mockFetcher.On("FetchPodDisruptionBudgets", mock.Anything).Return([]types.PodDisruptionBudgetInfo{}, nil)
+
+	results, err := NewAnalyzer(mockFetcher).AnalyzePodSchedulability(context.Background(), "default", false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].IsSchedulable)
+	assert.Contains(t, results[0].Reason, "; preempting 1 lower-priority pod(s) on node node-1 would make room")
+	require.NotNil(t, results[0].Preemption)
+	assert.Equal(t, []string{"batch/job-0 (priority 0)"}, results[0].Preemption.Victims)
+	mockFetcher.AssertExpectations(t)
+}
+
+func TestAnalyzePodSchedulability_PreemptionWithoutDisruptionBudgets(t *testing.T) {
+	running := []types.PodInfo{priorityPod("batch", "job-0", 0, "2", nil)}
+	running[0].NodeName = "node-1"
+
+	node := hostnameNode("node-1", "a")
+	node.AllocatableCPU = resource.MustParse("2")
+
+	mockFetcher := &MockFetcher{}
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").
+		Return([]types.PodInfo{priorityPod("default", "api-0", 1000, "1", nil)}, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return([]types.NodeInfo{node}, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return(running, nil)
+	mockFetcher.On("FetchPodDisruptionBudgets", mock.Anything).
+		Return([]types.PodDisruptionBudgetInfo(nil), errors.New("poddisruptionbudgets.policy is forbidden"))
+
+	results, err := NewAnalyzer(mockFetcher).AnalyzePodSchedulability(context.Background(), "default", false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NotNil(t, results[0].Preemption)
+	assert.Equal(t, []string{"batch/job-0 (priority 0)"}, results[0].Preemption.Victims)
+	assert.True(t, results[0].Preemption.PDBViolationsUnknown)
+	mockFetcher.AssertExpectations(t)
+}
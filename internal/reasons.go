@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// constraintCodes are the reason codes of hard scheduling constraints that remove nodes
+// before resources are compared.
+var constraintCodes = map[types.ReasonCode]bool{
+	types.ReasonCodeNodeUnavailable:         true,
+	types.ReasonCodeNodeSelectorMismatch:    true,
+	types.ReasonCodeNodeAffinityMismatch:    true,
+	types.ReasonCodeTaintNotTolerated:       true,
+	types.ReasonCodeVolumeConflict:          true,
+	types.ReasonCodeMaxVolumeCountExceeded:  true,
+	types.ReasonCodeHostPortConflict:        true,
+	types.ReasonCodePodAffinityConflict:     true,
+	types.ReasonCodeTopologySpreadViolation: true,
+	types.ReasonCodeTooManyPods:             true,
+}
+
+// resourceCode returns the reason code for a resource no node has enough of.
+func resourceCode(name corev1.ResourceName) types.ReasonCode {
+	switch name {
+	case corev1.ResourceCPU:
+		return types.ReasonCodeInsufficientCPU
+	case corev1.ResourceMemory:
+		return types.ReasonCodeInsufficientMemory
+	default:
+		return types.ReasonCodeInsufficientResource
+	}
+}
+
+// insufficientResourceDetail builds the reason for a requirement that exceeds what every
+// candidate node has, either allocatable or left free by running pods.
+//
+// Parameters:
+//   - field: Either "requests" or "limits"
+//   - scope: Whether the requirement exceeds the allocatable or the free amount
+//   - name: The resource name
+//   - requested: The pod's requirement
+//   - available: The largest allocatable or free amount across candidate nodes
+//
+// Returns:
+//   - types.ReasonDetail: The reason, with its message rendered
+func insufficientResourceDetail(field string, scope types.ReasonScope, name corev1.ResourceName,
+	requested, available resource.Quantity) types.ReasonDetail {
+	detail := types.ReasonDetail{
+		Code:      resourceCode(name),
+		Field:     field,
+		Scope:     scope,
+		Resources: []types.ResourceAmount{{Name: name, Requested: requested.DeepCopy(), Available: available.DeepCopy()}},
+	}
+	detail.Message = renderReason(detail)
+	return detail
+}
+
+// notColocatedDetail builds the reason for requirements that each fit on some node but
+// not all on the same one. The available amounts are the largest free amounts.
+func notColocatedDetail(field string, amounts []types.ResourceAmount) types.ReasonDetail {
+	detail := types.ReasonDetail{
+		Code:      types.ReasonCodeResourcesNotColocated,
+		Field:     field,
+		Scope:     types.ReasonScopeFree,
+		Resources: amounts,
+	}
+	detail.Message = renderReason(detail)
+	return detail
+}
+
+// renderReason renders a resource reason from its code and quantities. Other reasons carry
+// the message composed by the check that produced them, which is returned unchanged.
+func renderReason(detail types.ReasonDetail) string {
+	switch detail.Code {
+	case types.ReasonCodeInsufficientCPU, types.ReasonCodeInsufficientMemory, types.ReasonCodeInsufficientResource:
+		amount := detail.Resources[0]
+		switch {
+		case detail.Scope == types.ReasonScopeFree:
+			return fmt.Sprintf("%s.%s = %s exceeds the free %s left by running pods on all nodes (max free: %s)",
+				detail.Field, amount.Name, amount.Requested.String(), amount.Name, amount.Available.String())
+		case detail.Code == types.ReasonCodeInsufficientResource && amount.Available.IsZero():
+			return fmt.Sprintf("%s.%s = %s but no node provides %s",
+				detail.Field, amount.Name, amount.Requested.String(), amount.Name)
+		default:
+			return fmt.Sprintf("%s.%s = %s exceeds all node allocatable.%s (max: %s)",
+				detail.Field, amount.Name, amount.Requested.String(), amount.Name, amount.Available.String())
+		}
+	case types.ReasonCodeResourcesNotColocated:
+		if len(detail.Resources) == 2 && detail.Resources[0].Name == corev1.ResourceCPU &&
+			detail.Resources[1].Name == corev1.ResourceMemory {
+			cpu, memory := detail.Resources[0], detail.Resources[1]
+			return fmt.Sprintf("%s.cpu = %s and %s.memory = %s do not fit together on any single node "+
+				"(max free CPU: %s and max free memory: %s are on different nodes)",
+				detail.Field, cpu.Requested.String(), detail.Field, memory.Requested.String(),
+				cpu.Available.String(), memory.Available.String())
+		}
+		requirements := make([]string, 0, len(detail.Resources))
+		for _, amount := range detail.Resources {
+			requirements = append(requirements, fmt.Sprintf("%s.%s = %s", detail.Field, amount.Name, amount.Requested.String()))
+		}
+		return fmt.Sprintf("%s do not fit together on any single node", strings.Join(requirements, ", "))
+	}
+	return detail.Message
+}
+
+// renderReasons renders the reason details of a result as the Reason prose. CPU and memory
+// that both exceed the same kind of capacity are stated in one sentence, and several hard
+// constraints that together exclude every node are introduced as such.
+//
+// Parameters:
+//   - details: The reason details in the order they were found
+//
+// Returns:
+//   - string: The reasons joined with "; "
+func renderReasons(details []types.ReasonDetail) string {
+	var constraints, parts []string
+	for i := 0; i < len(details); i++ {
+		detail := details[i]
+		if constraintCodes[detail.Code] {
+			constraints = append(constraints, detail.Message)
+			continue
+		}
+		if i+1 < len(details) && detail.Code == types.ReasonCodeInsufficientCPU &&
+			details[i+1].Code == types.ReasonCodeInsufficientMemory && details[i+1].Scope == detail.Scope {
+			parts = append(parts, renderCPUAndMemory(detail, details[i+1]))
+			i++
+			continue
+		}
+		parts = append(parts, detail.Message)
+	}
+
+	if len(constraints) > 1 {
+		constraints = []string{"no node satisfies all scheduling constraints: " + strings.Join(constraints, "; ")}
+	}
+	return strings.Join(append(constraints, parts...), "; ")
+}
+
+// renderCPUAndMemory states that both CPU and memory exceed the same kind of capacity.
+func renderCPUAndMemory(cpuDetail, memoryDetail types.ReasonDetail) string {
+	cpu, memory := cpuDetail.Resources[0], memoryDetail.Resources[0]
+	if cpuDetail.Scope == types.ReasonScopeFree {
+		return fmt.Sprintf("%s.cpu = %s and %s.memory = %s exceed the free resources left by running pods on all nodes "+
+			"(max free CPU: %s, max free memory: %s)",
+			cpuDetail.Field, cpu.Requested.String(), memoryDetail.Field, memory.Requested.String(),
+			cpu.Available.String(), memory.Available.String())
+	}
+	return fmt.Sprintf("%s.cpu = %s and %s.memory = %s exceed all node allocatable resources (max CPU: %s, max memory: %s)",
+		cpuDetail.Field, cpu.Requested.String(), memoryDetail.Field, memory.Requested.String(),
+		cpu.Available.String(), memory.Available.String())
+}
+
+// messageDetail builds a reason whose message was composed by the check that found it.
+func messageDetail(code types.ReasonCode, message string, nodes, details []string) types.ReasonDetail {
+	return types.ReasonDetail{Code: code, Nodes: nodes, Details: details, Message: message}
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestRenderReasons(t *testing.T) {
+	cpu := func(scope types.ReasonScope, requested, available string) types.ReasonDetail {
+		return insufficientResourceDetail("requests", scope, corev1.ResourceCPU,
+			resource.MustParse(requested), resource.MustParse(available))
+	}
+	memory := func(scope types.ReasonScope, requested, available string) types.ReasonDetail {
+		return insufficientResourceDetail("requests", scope, corev1.ResourceMemory,
+			resource.MustParse(requested), resource.MustParse(available))
+	}
+
+	tests := []struct {
+		name     string
+		details  []types.ReasonDetail
+		expected string
+	}{
+		{
+			name:     "single resource",
+			details:  []types.ReasonDetail{cpu(types.ReasonScopeFree, "1", "500m")},
+			expected: "requests.cpu = 1 exceeds the free cpu left by running pods on all nodes (max free: 500m)",
+		},
+		{
+			name: "cpu and memory in the same scope",
+			details: []types.ReasonDetail{
+				cpu(types.ReasonScopeAllocatable, "3", "2"),
+				memory(types.ReasonScopeAllocatable, "8Gi", "4Gi"),
+			},
+			expected: "requests.cpu = 3 and requests.memory = 8Gi exceed all node allocatable resources " +
+				"(max CPU: 2, max memory: 4Gi)",
+		},
+		{
+			name: "extended resource no node provides",
+			details: []types.ReasonDetail{insufficientResourceDetail("limits", types.ReasonScopeAllocatable,
+				"nvidia.com/gpu", resource.MustParse("1"), resource.Quantity{})},
+			expected: "limits.nvidia.com/gpu = 1 but no node provides nvidia.com/gpu",
+		},
+		{
+			name: "resources not colocated",
+			details: []types.ReasonDetail{notColocatedDetail("requests", []types.ResourceAmount{
+				{Name: corev1.ResourceCPU, Requested: resource.MustParse("2"), Available: resource.MustParse("4")},
+				{Name: corev1.ResourceMemory, Requested: resource.MustParse("1Gi"), Available: resource.MustParse("8Gi")},
+				{Name: "nvidia.com/gpu", Requested: resource.MustParse("1"), Available: resource.MustParse("1")},
+			})},
+			expected: "requests.cpu = 2, requests.memory = 1Gi, requests.nvidia.com/gpu = 1 do not fit together on any single node",
+		},
+		{
+			name: "several constraints with a preemption note",
+			details: []types.ReasonDetail{
+				messageDetail(types.ReasonCodeTaintNotTolerated, "1 node(s) have untolerated taints (dedicated=gpu:NoSchedule)",
+					[]string{"gpu-1"}, []string{"dedicated=gpu:NoSchedule"}),
+				messageDetail(types.ReasonCodeNodeSelectorMismatch, "1 node(s) do not match the nodeSelector",
+					[]string{"arm-1"}, nil),
+				messageDetail(types.ReasonCodePreemptionPossible, "preempting 1 lower-priority pod(s) on node gpu-1 would make room",
+					[]string{"gpu-1"}, nil),
+			},
+			expected: "no node satisfies all scheduling constraints: 1 node(s) have untolerated taints " +
+				"(dedicated=gpu:NoSchedule); 1 node(s) do not match the nodeSelector; " +
+				"preempting 1 lower-priority pod(s) on node gpu-1 would make room",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, renderReasons(tt.details))
+		})
+	}
+}
+
+func TestAnalyzeSinglePod_ReasonCodes(t *testing.T) {
+	tainted := hostnameNode("gpu-1", "a")
+	tainted.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+	small := hostnameNode("small-1", "a")
+	small.AllocatableCPU = resource.MustParse("1")
+
+	pod := types.PodInfo{
+		Name:           "web",
+		Namespace:      "default",
+		RequestsCPU:    resource.MustParse("2"),
+		RequestsMemory: resource.MustParse("128Mi"),
+	}
+
+	result := NewAnalyzer(nil).analyzeSinglePod(pod, []types.NodeInfo{tainted, small}, false)
+
+	assert.False(t, result.IsSchedulable)
+	assert.Len(t, result.Reasons, 2)
+	assert.Equal(t, types.ReasonCodeInsufficientCPU, result.Reasons[0].Code)
+	assert.Equal(t, types.ReasonScopeAllocatable, result.Reasons[0].Scope)
+	assert.Equal(t, corev1.ResourceCPU, result.Reasons[0].Resources[0].Name)
+	assert.Equal(t, "2", result.Reasons[0].Resources[0].Requested.String())
+	assert.Equal(t, "1", result.Reasons[0].Resources[0].Available.String())
+	assert.Equal(t, types.ReasonCodeFittingNodesExcluded, result.Reasons[1].Code)
+	assert.Equal(t, []string{"gpu-1"}, result.Reasons[1].Nodes)
+	assert.Equal(t, []string{string(types.ReasonCodeTaintNotTolerated)}, result.Reasons[1].Details)
+	assert.Equal(t, renderReasons(result.Reasons), result.Reason)
+}
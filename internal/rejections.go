@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+)
+
+// nodeAffinityPredicate is the scheduler's wording for nodes that fail the pod's nodeSelector
+// or required node affinity.
+const nodeAffinityPredicate = "node(s) didn't match Pod's node affinity/selector"
+
+// nodeRejections lists, for every node the pod cannot be placed on, the reasons it was
+// rejected, and summarizes them as the scheduler does: "0/5 nodes are available: 2 Insufficient
+// cpu, 3 node(s) had untolerated taint {dedicated: gpu}." Nodes removed by a constraint carry
+// that constraint's reasons; the remaining candidates carry one "Insufficient <resource>" per
+// resource they lack.
+//
+// Parameters:
+//   - exclusions: The constraints applied to the pod, in evaluation order
+//   - nodeFits: The per-node fit results of the candidates left after the constraints
+//   - totalNodes: The number of nodes in the cluster
+//
+// Returns:
+//   - []types.NodeRejection: The rejected nodes with their reasons, ordered by node name
+//   - string: The scheduler-style summary of the rejection reasons
+func nodeRejections(exclusions []nodeExclusion, nodeFits []types.NodeFit,
+	totalNodes int) ([]types.NodeRejection, string) {
+	var rejections []types.NodeRejection
+	for _, exclusion := range exclusions {
+		for _, node := range exclusion.nodes {
+			rejections = append(rejections, types.NodeRejection{Node: node.Name, Reasons: exclusion.predicates[node.Name]})
+		}
+	}
+	for _, fit := range nodeFits {
+		if fit.Fits {
+			continue
+		}
+		reasons := make([]string, 0, len(fit.InsufficientResources))
+		for _, name := range fit.InsufficientResources {
+			reasons = append(reasons, "Insufficient "+name)
+		}
+		rejections = append(rejections, types.NodeRejection{Node: fit.Name, Reasons: reasons})
+	}
+	sort.Slice(rejections, func(i, j int) bool { return rejections[i].Node < rejections[j].Node })
+
+	return rejections, rejectionSummary(rejections, totalNodes)
+}
+
+// rejectionSummary counts the nodes per reason and renders them in the scheduler's format,
+// with the "<count> <reason>" items sorted as strings.
+func rejectionSummary(rejections []types.NodeRejection, totalNodes int) string {
+	counts := rejectionCounts(rejections)
+
+	items := make([]string, 0, len(counts))
+	for reason, count := range counts {
+		items = append(items, fmt.Sprintf("%d %s", count, reason))
+	}
+	sort.Strings(items)
+
+	available := totalNodes - len(rejections)
+	if len(items) == 0 {
+		return fmt.Sprintf("%d/%d nodes are available.", available, totalNodes)
+	}
+	return fmt.Sprintf("%d/%d nodes are available: %s.", available, totalNodes, strings.Join(items, ", "))
+}
+
+// rejectionCounts returns the number of rejected nodes per reason.
+func rejectionCounts(rejections []types.NodeRejection) map[string]int {
+	counts := make(map[string]int)
+	for _, rejection := range rejections {
+		for _, reason := range rejection.Reasons {
+			counts[reason]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestRejectionSummary(t *testing.T) {
+	tests := []struct {
+		name       string
+		rejections []types.NodeRejection
+		totalNodes int
+		expected   string
+	}{
+		{
+			name: "reasons counted and sorted",
+			rejections: []types.NodeRejection{
+				{Node: "node-1", Reasons: []string{"Insufficient cpu", "Insufficient memory"}},
+				{Node: "node-2", Reasons: []string{"Insufficient memory"}},
+				{Node: "node-3", Reasons: []string{"node(s) had untolerated taint {dedicated: gpu}"}},
+			},
+			totalNodes: 3,
+			expected: "0/3 nodes are available: 1 Insufficient cpu, 1 node(s) had untolerated taint {dedicated: gpu}, " +
+				"2 Insufficient memory.",
+		},
+		{
+			name:       "no nodes",
+			totalNodes: 0,
+			expected:   "0/0 nodes are available.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rejectionSummary(tt.rejections, tt.totalNodes))
+		})
+	}
+}
+
+func TestAnalyzeSinglePod_NodeRejections(t *testing.T) {
+	node := func(name string, cpu string) types.NodeInfo {
+		n := hostnameNode(name, "a")
+		n.AllocatableCPU = resource.MustParse(cpu)
+		n.AllocatablePods = 110
+		return n
+	}
+
+	tainted := node("gpu-1", "16")
+	tainted.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+	cordoned := node("old-1", "16")
+	cordoned.Unschedulable = true
+	full := node("full-1", "16")
+	full.PodCount = 110
+	mismatched := node("arm-1", "16")
+	mismatched.Labels["kubernetes.io/arch"] = "arm64"
+
+	nodes := []types.NodeInfo{node("small-2", "1"), tainted, cordoned, full, mismatched, node("small-1", "1")}
+	for i := range nodes {
+		if _, ok := nodes[i].Labels["kubernetes.io/arch"]; !ok {
+			nodes[i].Labels["kubernetes.io/arch"] = "amd64"
+		}
+	}
+
+	pod := types.PodInfo{
+		Name:           "web",
+		Namespace:      "default",
+		RequestsCPU:    resource.MustParse("2"),
+		RequestsMemory: resource.MustParse("128Mi"),
+		NodeSelector:   map[string]string{"kubernetes.io/arch": "amd64"},
+	}
+
+	result := NewAnalyzer(nil).analyzeSinglePod(pod, nodes, false)
+
+	assert.False(t, result.IsSchedulable)
+	assert.Equal(t, []types.NodeRejection{
+		{Node: "arm-1", Reasons: []string{"node(s) didn't match Pod's node affinity/selector"}},
+		{Node: "full-1", Reasons: []string{"Too many pods"}},
+		{Node: "gpu-1", Reasons: []string{"node(s) had untolerated taint {dedicated: gpu}"}},
+		{Node: "old-1", Reasons: []string{"node(s) were unschedulable"}},
+		{Node: "small-1", Reasons: []string{"Insufficient cpu"}},
+		{Node: "small-2", Reasons: []string{"Insufficient cpu"}},
+	}, result.NodeRejections)
+	assert.Equal(t, "0/6 nodes are available: 1 Too many pods, 1 node(s) didn't match Pod's node affinity/selector, "+
+		"1 node(s) had untolerated taint {dedicated: gpu}, 1 node(s) were unschedulable, 2 Insufficient cpu.",
+		result.RejectionSummary)
+}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
 	"github.com/syossan27/k8s-pending-resource-inspector/pkg/utils"
 	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // OutputFormat represents the different output formats supported for generating reports.
@@ -86,18 +88,216 @@ func (r *Reporter) GenerateReport(ctx context.Context, results []types.AnalysisR
 }
 
 func (r *Reporter) generateHumanReport(results []types.AnalysisResult) error {
+	unscheduled, preempting, bound := splitByCategory(results)
+	if len(unscheduled) > 0 {
+		r.writeUnscheduledPods(unscheduled)
+	}
+	if len(preempting) > 0 {
+		r.writePreemptingPods(preempting)
+	}
+	if len(bound) > 0 {
+		r.writeBoundNotRunningPods(bound)
+	}
+	return nil
+}
+
+// splitByCategory separates pods waiting for a node, pods waiting for preemption victims to
+// terminate and pods bound to a node that are not running yet, keeping the order of the results.
+func splitByCategory(results []types.AnalysisResult) ([]types.AnalysisResult, []types.AnalysisResult, []types.AnalysisResult) {
+	unscheduled := make([]types.AnalysisResult, 0, len(results))
+	preempting := make([]types.AnalysisResult, 0)
+	bound := make([]types.AnalysisResult, 0)
+	for _, result := range results {
+		switch result.Category {
+		case types.PendingCategoryBoundNotRunning:
+			bound = append(bound, result)
+		case types.PendingCategoryPreemptionInProgress:
+			preempting = append(preempting, result)
+		default:
+			unscheduled = append(unscheduled, result)
+		}
+	}
+	return unscheduled, preempting, bound
+}
+
+// writePreemptingPods lists the pods nominated to a node whose preemption victims are
+// still terminating.
+func (r *Reporter) writePreemptingPods(results []types.AnalysisResult) {
+	fmt.Fprintf(r.writer, "Found %d pod(s) with preemption in progress:\n\n", len(results))
+	for _, result := range results {
+		fmt.Fprintf(r.writer, "[~] Pod: %s (nominated node %s)\n", result.Pod.Name, result.Pod.NominatedNodeName)
+		fmt.Fprintf(r.writer, "→ Reason: %s\n", result.Reason)
+		fmt.Fprintf(r.writer, "→ Suggested: %s\n", result.Suggestion)
+		fmt.Fprintln(r.writer)
+	}
+}
+
+// writeBoundNotRunningPods lists the pods that are bound to a node but whose containers
+// have not started, with the reason each is waiting.
+func (r *Reporter) writeBoundNotRunningPods(results []types.AnalysisResult) {
+	fmt.Fprintf(r.writer, "Found %d pod(s) bound to a node but not running:\n\n", len(results))
+	for _, result := range results {
+		fmt.Fprintf(r.writer, "[!] Pod: %s (node %s)\n", result.Pod.Name, result.Pod.NodeName)
+		fmt.Fprintf(r.writer, "→ Reason: %s\n", result.Reason)
+		fmt.Fprintf(r.writer, "→ Suggested: %s\n", result.Suggestion)
+		fmt.Fprintln(r.writer)
+	}
+}
+
+func (r *Reporter) writeUnscheduledPods(results []types.AnalysisResult) {
 	fmt.Fprintf(r.writer, "Found %d pending pod(s) for analysis:\n\n", len(results))
+	if unplaced, shortfall := collectiveShortfall(results); unplaced > 0 {
+		fmt.Fprintf(r.writer, "%d pod(s) fit on their own but not together; the cluster falls short by %s\n\n",
+			unplaced, formatResourceList(shortfall))
+	}
 	for _, result := range results {
 		if result.IsSchedulable {
 			fmt.Fprintf(r.writer, "[✓] Pod: %s - Schedulable\n", result.Pod.Name)
+			r.writeEmptyDirMemory(result)
+			r.writeSchedulerReports(result)
 		} else {
 			fmt.Fprintf(r.writer, "[✗] Pod: %s\n", result.Pod.Name)
 			fmt.Fprintf(r.writer, "→ Reason: %s\n", result.Reason)
 			fmt.Fprintf(r.writer, "→ Suggested: %s\n", result.Suggestion)
+			r.writePreemption(result.Preemption)
+			r.writeEffectiveRequests(result.Pod)
+			r.writePodOverhead(result.Pod)
+			r.writeEmptyDirMemory(result)
+			r.writeNodeCapacities(result.Nodes)
+			r.writeNodeRejections(result)
+			r.writeSchedulerReports(result)
 		}
 		fmt.Fprintln(r.writer)
 	}
-	return nil
+}
+
+// collectiveShortfall counts the pods that fit on their own but not together with the other
+// pending pods, and sums what the closest node lacks for each of them. As every unplaced pod
+// is charged to its closest node in the simulation, the sum is the capacity the cluster lacks.
+func collectiveShortfall(results []types.AnalysisResult) (int, corev1.ResourceList) {
+	unplaced := 0
+	var total corev1.ResourceList
+	for _, result := range results {
+		if !result.CollectivelyUnplaced {
+			continue
+		}
+		unplaced++
+		for name, quantity := range result.Shortfall {
+			if total == nil {
+				total = make(corev1.ResourceList)
+			}
+			sum := total[name]
+			sum.Add(quantity)
+			total[name] = sum
+		}
+	}
+	return unplaced, total
+}
+
+// writeEffectiveRequests explains where the pod's requests come from when init or sidecar
+// containers, rather than the app containers alone, determine them.
+func (r *Reporter) writeEffectiveRequests(pod types.PodInfo) {
+	if !drivenByInitContainers(pod.RequestsCPUSource) && !drivenByInitContainers(pod.RequestsMemorySource) {
+		return
+	}
+	fmt.Fprintf(r.writer, "→ Effective requests: cpu=%s (%s), memory=%s (%s)\n",
+		pod.RequestsCPU.String(), sourceOrNone(pod.RequestsCPUSource),
+		pod.RequestsMemory.String(), sourceOrNone(pod.RequestsMemorySource))
+}
+
+// writePreemption names the node and victims of a possible preemption, and the
+// PodDisruptionBudgets it would violate.
+func (r *Reporter) writePreemption(preemption *types.PreemptionCandidate) {
+	if preemption == nil {
+		return
+	}
+	fmt.Fprintf(r.writer, "→ Preemption: node %s after evicting %s", preemption.NodeName,
+		strings.Join(preemption.Victims, ", "))
+	if preemption.PDBViolationsUnknown {
+		fmt.Fprint(r.writer, " (PodDisruptionBudgets not checked)")
+	} else if preemption.PDBViolations > 0 {
+		fmt.Fprintf(r.writer, " (violates %d PodDisruptionBudget(s))", preemption.PDBViolations)
+	}
+	fmt.Fprintln(r.writer)
+}
+
+// writeSchedulerReports shows the latest messages of the scheduler itself, and where they
+// disagree with the analysis.
+func (r *Reporter) writeSchedulerReports(result types.AnalysisResult) {
+	for _, report := range result.SchedulerReports {
+		fmt.Fprintf(r.writer, "→ Scheduler (%s): %s\n", report.Source, report.Message)
+	}
+	if result.SchedulerDisagreement != "" {
+		fmt.Fprintf(r.writer, "→ Disagreement: %s\n", result.SchedulerDisagreement)
+	}
+}
+
+// writePodOverhead shows the RuntimeClass overhead that is included in the effective requests.
+func (r *Reporter) writePodOverhead(pod types.PodInfo) {
+	if len(pod.Overhead) == 0 {
+		return
+	}
+	fmt.Fprintf(r.writer, "→ Pod overhead: cpu=%s, memory=%s (runtimeClass %s, included in requests)\n",
+		pod.Overhead.Cpu().String(), pod.Overhead.Memory().String(), pod.RuntimeClassName)
+}
+
+// writeEmptyDirMemory shows the size limit of the pod's memory-backed emptyDir volumes, which
+// is not part of the requests, and warns when it exceeds the memory limit or free memory.
+func (r *Reporter) writeEmptyDirMemory(result types.AnalysisResult) {
+	if result.Pod.MemoryBackedEmptyDir == nil {
+		return
+	}
+	fmt.Fprintf(r.writer, "→ Memory-backed emptyDir: %s (not included in requests)\n",
+		result.Pod.MemoryBackedEmptyDir.String())
+	if result.EmptyDirMemoryWarning != "" {
+		fmt.Fprintf(r.writer, "→ Warning: %s\n", result.EmptyDirMemoryWarning)
+	}
+}
+
+// formatExtendedFree renders the free amounts of extended resources as a suffix for a
+// node capacity line, e.g. ", nvidia.com/gpu=0".
+func formatExtendedFree(free corev1.ResourceList) string {
+	var b strings.Builder
+	for _, name := range sortedResourceNames(free) {
+		quantity := free[name]
+		fmt.Fprintf(&b, ", %s=%s", name, quantity.String())
+	}
+	return b.String()
+}
+
+func drivenByInitContainers(source string) bool {
+	return source != "" && source != "app containers"
+}
+
+func sourceOrNone(source string) string {
+	if source == "" {
+		return "none"
+	}
+	return source
+}
+
+func (r *Reporter) writeNodeCapacities(nodes []types.NodeFit) {
+	if len(nodes) == 0 {
+		return
+	}
+	fmt.Fprintln(r.writer, "→ Node capacity:")
+	for _, node := range nodes {
+		fmt.Fprintf(r.writer, "    %s: allocatable cpu=%s, memory=%s; free cpu=%s, memory=%s%s\n",
+			node.Name, node.AllocatableCPU.String(), node.AllocatableMemory.String(),
+			node.FreeCPU.String(), node.FreeMemory.String(), formatExtendedFree(node.ExtendedFree))
+	}
+}
+
+// writeNodeRejections prints the scheduler-style summary of why nodes reject the pod,
+// followed by the reasons of each node.
+func (r *Reporter) writeNodeRejections(result types.AnalysisResult) {
+	if result.RejectionSummary == "" {
+		return
+	}
+	fmt.Fprintf(r.writer, "→ Nodes: %s\n", result.RejectionSummary)
+	for _, rejection := range result.NodeRejections {
+		fmt.Fprintf(r.writer, "    %s: %s\n", rejection.Node, strings.Join(rejection.Reasons, ", "))
+	}
 }
 
 func (r *Reporter) generateJSONReport(results []types.AnalysisResult, clusterName string, totalNodes int) error {
@@ -151,8 +351,9 @@ func (r *Reporter) SendSlackNotification(ctx context.Context, webhookURL string,
 }
 
 func (r *Reporter) buildClusterAnalysis(results []types.AnalysisResult, clusterName string, totalNodes int) types.ClusterAnalysis {
+	unscheduled, preempting, bound := splitByCategory(results)
 	unschedulablePods := make([]types.AnalysisResult, 0)
-	for _, result := range results {
+	for _, result := range unscheduled {
 		if !result.IsSchedulable {
 			unschedulablePods = append(unschedulablePods, result)
 		}
@@ -160,14 +361,28 @@ func (r *Reporter) buildClusterAnalysis(results []types.AnalysisResult, clusterN
 
 	summary := fmt.Sprintf("Found %d pending pods, %d unschedulable due to resource constraints",
 		len(results), len(unschedulablePods))
+	if len(bound) > 0 {
+		summary += fmt.Sprintf(", %d bound to a node but not running", len(bound))
+	}
+	if len(preempting) > 0 {
+		summary += fmt.Sprintf(", %d with preemption in progress", len(preempting))
+	}
+	unplaced, shortfall := collectiveShortfall(unscheduled)
+	if unplaced > 0 {
+		summary += fmt.Sprintf(", %d fitting on their own but not together", unplaced)
+	}
 
 	return types.ClusterAnalysis{
-		Timestamp:         time.Now(),
-		ClusterName:       clusterName,
-		TotalNodes:        totalNodes,
-		TotalPendingPods:  len(results),
-		UnschedulablePods: unschedulablePods,
-		Summary:           summary,
+		SchemaVersion:       types.ClusterAnalysisSchemaVersion,
+		Timestamp:           time.Now(),
+		ClusterName:         clusterName,
+		TotalNodes:          totalNodes,
+		TotalPendingPods:    len(results),
+		UnschedulablePods:   unschedulablePods,
+		BoundNotRunningPods: bound,
+		PreemptingPods:      preempting,
+		CollectiveShortfall: shortfall,
+		Summary:             summary,
 	}
 }
 
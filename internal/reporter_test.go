@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
 	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
@@ -46,6 +47,41 @@ func TestJSONOutput(t *testing.T) {
 	assert.Len(t, analysis.UnschedulablePods, 1)
 }
 
+func TestJSONOutput_ReasonCodes(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewReporter(&buf, OutputFormatJSON)
+
+	results := []types.AnalysisResult{
+		{
+			Pod:    types.PodInfo{Name: "test-pod", Namespace: "default"},
+			Reason: "requests.cpu = 3 exceeds all node allocatable.cpu (max: 2)",
+			Reasons: []types.ReasonDetail{
+				insufficientResourceDetail("requests", types.ReasonScopeAllocatable, corev1.ResourceCPU,
+					resource.MustParse("3"), resource.MustParse("2")),
+			},
+		},
+	}
+
+	err := reporter.GenerateReport(context.Background(), results, "test-cluster", 1)
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &raw))
+	assert.Equal(t, types.ClusterAnalysisSchemaVersion, raw["schemaVersion"])
+
+	pods := raw["unschedulablePods"].([]interface{})
+	reasons := pods[0].(map[string]interface{})["reasons"].([]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"code":  "InsufficientCPU",
+		"field": "requests",
+		"scope": "Allocatable",
+		"resources": []interface{}{
+			map[string]interface{}{"name": "cpu", "requested": "3", "available": "2"},
+		},
+		"message": "requests.cpu = 3 exceeds all node allocatable.cpu (max: 2)",
+	}, reasons[0])
+}
+
 func TestYAMLOutput(t *testing.T) {
 	var buf bytes.Buffer
 	reporter := NewReporter(&buf, OutputFormatYAML)
@@ -99,23 +135,55 @@ func TestNewReporter(t *testing.T) {
 func TestGenerateHumanReport(t *testing.T) {
 	var buf bytes.Buffer
 	reporter := NewReporter(&buf, OutputFormatHuman)
+	emptyDirSize := resource.MustParse("8Gi")
 
 	results := []types.AnalysisResult{
 		{
 			Pod: types.PodInfo{
-				Name:      "schedulable-pod",
-				Namespace: "default",
+				Name:                 "schedulable-pod",
+				Namespace:            "default",
+				MemoryBackedEmptyDir: &emptyDirSize,
 			},
 			IsSchedulable: true,
+			EmptyDirMemoryWarning: "memory-backed emptyDir volumes may hold 8Gi, more than the free memory of any node " +
+				"the pod can be placed on (max: 4Gi); filling them can exhaust the node's memory",
+			SchedulerReports: []types.SchedulerReport{
+				{Source: "FailedScheduling event", Message: "0/2 nodes are available: 2 Insufficient cpu."},
+			},
+			SchedulerDisagreement: "the scheduler reports the pod as unschedulable",
 		},
 		{
 			Pod: types.PodInfo{
-				Name:      "unschedulable-pod",
-				Namespace: "default",
+				Name:                 "unschedulable-pod",
+				Namespace:            "default",
+				RequestsCPU:          resource.MustParse("100m"),
+				RequestsMemory:       resource.MustParse("16Gi"),
+				RequestsCPUSource:    "app containers",
+				RequestsMemorySource: "init container migrate",
+				RuntimeClassName:     "kata",
+				Overhead: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("160Mi"),
+				},
 			},
 			IsSchedulable: false,
 			Reason:        "Insufficient CPU",
 			Suggestion:    "Add more nodes",
+			Preemption: &types.PreemptionCandidate{
+				NodeName:      "node1",
+				Victims:       []string{"batch/job-0 (priority 0)"},
+				PDBViolations: 1,
+			},
+			Nodes: []types.NodeFit{
+				{
+					Name:              "node1",
+					AllocatableCPU:    resource.MustParse("2"),
+					AllocatableMemory: resource.MustParse("4Gi"),
+					FreeCPU:           resource.MustParse("500m"),
+					FreeMemory:        resource.MustParse("1Gi"),
+					ExtendedFree:      corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("0")},
+				},
+			},
 		},
 	}
 
@@ -125,9 +193,18 @@ func TestGenerateHumanReport(t *testing.T) {
 	output := buf.String()
 	assert.Contains(t, output, "Found 2 pending pod(s) for analysis:")
 	assert.Contains(t, output, "[✓] Pod: schedulable-pod - Schedulable")
+	assert.Contains(t, output, "→ Scheduler (FailedScheduling event): 0/2 nodes are available: 2 Insufficient cpu.")
+	assert.Contains(t, output, "→ Disagreement: the scheduler reports the pod as unschedulable")
+	assert.Contains(t, output, "→ Memory-backed emptyDir: 8Gi (not included in requests)")
+	assert.Contains(t, output, "→ Warning: memory-backed emptyDir volumes may hold 8Gi, more than the free memory")
 	assert.Contains(t, output, "[✗] Pod: unschedulable-pod")
 	assert.Contains(t, output, "→ Reason: Insufficient CPU")
 	assert.Contains(t, output, "→ Suggested: Add more nodes")
+	assert.Contains(t, output, "→ Preemption: node node1 after evicting batch/job-0 (priority 0) (violates 1 PodDisruptionBudget(s))")
+	assert.Contains(t, output, "→ Effective requests: cpu=100m (app containers), memory=16Gi (init container migrate)")
+	assert.Contains(t, output, "→ Pod overhead: cpu=250m, memory=160Mi (runtimeClass kata, included in requests)")
+	assert.Contains(t, output, "→ Node capacity:")
+	assert.Contains(t, output, "node1: allocatable cpu=2, memory=4Gi; free cpu=500m, memory=1Gi, nvidia.com/gpu=0")
 }
 
 func TestGenerateReport_UnsupportedFormat(t *testing.T) {
@@ -175,6 +252,139 @@ func TestSendPrometheusMetrics(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBuildClusterAnalysis_BoundNotRunning(t *testing.T) {
+	reporter := NewReporter(&bytes.Buffer{}, OutputFormatJSON)
+
+	results := []types.AnalysisResult{
+		{
+			Pod:           types.PodInfo{Name: "unschedulable-pod", Namespace: "default"},
+			Category:      types.PendingCategoryUnscheduled,
+			IsSchedulable: false,
+		},
+		{
+			Pod:           types.PodInfo{Name: "pulling-pod", Namespace: "default", NodeName: "node1"},
+			Category:      types.PendingCategoryBoundNotRunning,
+			IsSchedulable: true,
+			Reason:        "bound to node node1 but not running: container app is ImagePullBackOff",
+		},
+	}
+
+	analysis := reporter.buildClusterAnalysis(results, "test-cluster", 1)
+
+	assert.Len(t, analysis.UnschedulablePods, 1)
+	require.Len(t, analysis.BoundNotRunningPods, 1)
+	assert.Equal(t, "pulling-pod", analysis.BoundNotRunningPods[0].Pod.Name)
+	assert.Equal(t, "Found 2 pending pods, 1 unschedulable due to resource constraints, "+
+		"1 bound to a node but not running", analysis.Summary)
+}
+
+func TestGenerateHumanReport_BoundNotRunning(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewReporter(&buf, OutputFormatHuman)
+
+	results := []types.AnalysisResult{
+		{
+			Pod:        types.PodInfo{Name: "pulling-pod", Namespace: "default", NodeName: "node1"},
+			Category:   types.PendingCategoryBoundNotRunning,
+			Reason:     "bound to node node1 but not running: container app is ImagePullBackOff",
+			Suggestion: "Check the image name and tag, registry availability and imagePullSecrets",
+		},
+	}
+
+	err := reporter.GenerateReport(context.Background(), results, "test-cluster", 1)
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.NotContains(t, output, "pending pod(s) for analysis")
+	assert.Contains(t, output, "Found 1 pod(s) bound to a node but not running:")
+	assert.Contains(t, output, "[!] Pod: pulling-pod (node node1)")
+	assert.Contains(t, output, "→ Reason: bound to node node1 but not running: container app is ImagePullBackOff")
+}
+
+func TestGenerateHumanReport_PreemptionInProgress(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewReporter(&buf, OutputFormatHuman)
+
+	results := []types.AnalysisResult{
+		{
+			Pod:           types.PodInfo{Name: "api-0", Namespace: "web", NominatedNodeName: "node1"},
+			Category:      types.PendingCategoryPreemptionInProgress,
+			IsSchedulable: true,
+			Reason:        "preemption in progress: nominated to node node1, waiting for 1 victim(s) to terminate: batch/job-1 (priority 0)",
+		},
+	}
+
+	err := reporter.GenerateReport(context.Background(), results, "test-cluster", 1)
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.NotContains(t, output, "pending pod(s) for analysis")
+	assert.Contains(t, output, "Found 1 pod(s) with preemption in progress:")
+	assert.Contains(t, output, "[~] Pod: api-0 (nominated node node1)")
+
+	analysis := reporter.buildClusterAnalysis(results, "test-cluster", 1)
+	assert.Empty(t, analysis.UnschedulablePods)
+	require.Len(t, analysis.PreemptingPods, 1)
+	assert.Equal(t, "Found 1 pending pods, 0 unschedulable due to resource constraints, "+
+		"1 with preemption in progress", analysis.Summary)
+}
+
+func TestGenerateReport_CollectiveShortfall(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewReporter(&buf, OutputFormatHuman)
+
+	unplaced := func(name, cpu string) types.AnalysisResult {
+		return types.AnalysisResult{
+			Pod:                  types.PodInfo{Name: name, Namespace: "default"},
+			Category:             types.PendingCategoryUnscheduled,
+			CollectivelyUnplaced: true,
+			Shortfall:            corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+		}
+	}
+	results := []types.AnalysisResult{
+		{Pod: types.PodInfo{Name: "web-0", Namespace: "default"}, IsSchedulable: true},
+		unplaced("web-1", "500m"),
+		unplaced("web-2", "1"),
+	}
+
+	err := reporter.GenerateReport(context.Background(), results, "test-cluster", 1)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "2 pod(s) fit on their own but not together; the cluster falls short by cpu=1500m")
+
+	analysis := reporter.buildClusterAnalysis(results, "test-cluster", 1)
+	cpu := analysis.CollectiveShortfall[corev1.ResourceCPU]
+	assert.Equal(t, "1500m", cpu.String())
+	assert.Equal(t, "Found 3 pending pods, 2 unschedulable due to resource constraints, "+
+		"2 fitting on their own but not together", analysis.Summary)
+}
+
+func TestGenerateHumanReport_NodeRejections(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewReporter(&buf, OutputFormatHuman)
+
+	results := []types.AnalysisResult{
+		{
+			Pod:              types.PodInfo{Name: "web", Namespace: "default"},
+			Category:         types.PendingCategoryUnscheduled,
+			Reason:           "requests.cpu = 2 exceeds all node allocatable.cpu (max: 1)",
+			RejectionSummary: "0/2 nodes are available: 1 Insufficient cpu, 1 node(s) had untolerated taint {dedicated: gpu}.",
+			NodeRejections: []types.NodeRejection{
+				{Node: "gpu-1", Reasons: []string{"node(s) had untolerated taint {dedicated: gpu}"}},
+				{Node: "small-1", Reasons: []string{"Insufficient cpu"}},
+			},
+		},
+	}
+
+	err := reporter.GenerateReport(context.Background(), results, "test-cluster", 2)
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.Contains(t, output, "→ Nodes: 0/2 nodes are available: 1 Insufficient cpu, "+
+		"1 node(s) had untolerated taint {dedicated: gpu}.")
+	assert.Contains(t, output, "    gpu-1: node(s) had untolerated taint {dedicated: gpu}\n")
+	assert.Contains(t, output, "    small-1: Insufficient cpu\n")
+}
+
 func TestBuildClusterAnalysis(t *testing.T) {
 	var buf bytes.Buffer
 	reporter := NewReporter(&buf, OutputFormatJSON)
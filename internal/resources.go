@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// extendedRequirements returns the amounts of every resource other than CPU and memory that
+// the pod needs on a single node, such as nvidia.com/gpu, hugepages-2Mi or ephemeral-storage.
+// When includeLimits is set, a limit replaces the request for the same resource.
+//
+// Parameters:
+//   - pod: The pod whose requirements are collected
+//   - includeLimits: If true, prefers limits over requests
+//
+// Returns:
+//   - corev1.ResourceList: Non-zero requirements keyed by resource name, or nil if there are none
+func extendedRequirements(pod types.PodInfo, includeLimits bool) corev1.ResourceList {
+	var extended corev1.ResourceList
+
+	add := func(list corev1.ResourceList) {
+		for name, quantity := range list {
+			if !isExtendedResource(name) || quantity.IsZero() {
+				continue
+			}
+			if extended == nil {
+				extended = make(corev1.ResourceList)
+			}
+			extended[name] = quantity.DeepCopy()
+		}
+	}
+
+	add(pod.Requests)
+	if includeLimits {
+		add(pod.Limits)
+	}
+
+	return extended
+}
+
+// isExtendedResource reports whether a resource is checked by the generic per-dimension
+// logic rather than the dedicated CPU and memory handling.
+func isExtendedResource(name corev1.ResourceName) bool {
+	return name != corev1.ResourceCPU && name != corev1.ResourceMemory
+}
+
+// sortedResourceNames returns the names in a resource list in lexical order, so that
+// reasons and reports are deterministic.
+func sortedResourceNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// freeResource returns how much of a resource is left on a node after subtracting the
+// requests of pods already bound to it. Values never go below zero.
+func freeResource(node types.NodeInfo, name corev1.ResourceName) resource.Quantity {
+	free := node.Allocatable[name].DeepCopy()
+	free.Sub(node.Requested[name])
+	if free.Sign() < 0 {
+		free = resource.Quantity{}
+	}
+	return free
+}
+
+// insufficientResources lists the resources for which a node does not have enough free
+// capacity for the pod, CPU and memory first and then extended resources in lexical order.
+//
+// Parameters:
+//   - node: The node to check
+//   - podCPU, podMemory: The pod's CPU and memory requirements
+//   - extended: The pod's requirements for all other resources
+//
+// Returns:
+//   - []string: The names of the resources that do not fit, or nil if the node fits the pod
+func insufficientResources(node types.NodeInfo, podCPU, podMemory resource.Quantity, extended corev1.ResourceList) []string {
+	var insufficient []string
+
+	freeCPU, freeMemory := freeResources(node)
+	if podCPU.Cmp(freeCPU) > 0 {
+		insufficient = append(insufficient, string(corev1.ResourceCPU))
+	}
+	if podMemory.Cmp(freeMemory) > 0 {
+		insufficient = append(insufficient, string(corev1.ResourceMemory))
+	}
+
+	for _, name := range sortedResourceNames(extended) {
+		free := freeResource(node, name)
+		required := extended[name]
+		if required.Cmp(free) > 0 {
+			insufficient = append(insufficient, string(name))
+		}
+	}
+
+	return insufficient
+}
+
+// buildFitReason explains why no candidate node can hold the pod. CPU and memory are
+// explained by buildResourceReason when no node can hold both of them; every extended
+// resource that exceeds the capacity of all nodes gets its own reason. When each resource
+// fits somewhere on its own, the reason states that they do not fit together on one node.
+//
+// Parameters:
+//   - resourceType: Either "requests" or "limits", used in the wording
+//   - podCPU, podMemory: The pod's CPU and memory requirements
+//   - extended: The pod's requirements for all other resources
+//   - candidates: The nodes the pod may be placed on
+//   - nodeFits: The per-node fit results for the candidates
+//   - maxAvailableCPU, maxAvailableMemory: Largest allocatable values across candidate nodes
+//   - maxFreeCPU, maxFreeMemory: Largest free values across candidate nodes
+//
+// Returns:
+//   - []types.ReasonDetail: The reasons the pod cannot be scheduled
+//   - string: A suggestion for resolving the issue
+func (a *Analyzer) buildFitReason(resourceType string, podCPU, podMemory resource.Quantity,
+	extended corev1.ResourceList, candidates []types.NodeInfo, nodeFits []types.NodeFit,
+	maxAvailableCPU, maxAvailableMemory, maxFreeCPU, maxFreeMemory resource.Quantity) ([]types.ReasonDetail, string) {
+	if len(extended) == 0 {
+		return a.buildResourceReason(resourceType, podCPU, podMemory,
+			maxAvailableCPU, maxAvailableMemory, maxFreeCPU, maxFreeMemory)
+	}
+
+	var reasons []types.ReasonDetail
+	var suggestions []string
+	if !anyNodeFitsCPUAndMemory(nodeFits) {
+		details, suggestion := a.buildResourceReason(resourceType, podCPU, podMemory,
+			maxAvailableCPU, maxAvailableMemory, maxFreeCPU, maxFreeMemory)
+		reasons = append(reasons, details...)
+		suggestions = append(suggestions, suggestion)
+	}
+
+	for _, name := range sortedResourceNames(extended) {
+		detail, suggestion, ok := extendedResourceReason(resourceType, name, extended[name], candidates)
+		if ok {
+			reasons = append(reasons, detail)
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	if len(reasons) > 0 {
+		return reasons, strings.Join(suggestions, "; ")
+	}
+
+	amounts := []types.ResourceAmount{
+		{Name: corev1.ResourceCPU, Requested: podCPU.DeepCopy(), Available: maxFreeCPU.DeepCopy()},
+		{Name: corev1.ResourceMemory, Requested: podMemory.DeepCopy(), Available: maxFreeMemory.DeepCopy()},
+	}
+	formatted := []string{
+		fmt.Sprintf("cpu=%s", podCPU.String()),
+		fmt.Sprintf("memory=%s", podMemory.String()),
+	}
+	for _, name := range sortedResourceNames(extended) {
+		quantity := extended[name]
+		_, maxFree := maxExtendedResource(candidates, name)
+		amounts = append(amounts, types.ResourceAmount{Name: name, Requested: quantity.DeepCopy(), Available: maxFree})
+		formatted = append(formatted, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+
+	suggestion := fmt.Sprintf("Lower the %s so that all of them fit within one node, or add a node with at least %s free",
+		resourceType, strings.Join(formatted, ", "))
+	return []types.ReasonDetail{notColocatedDetail(resourceType, amounts)}, suggestion
+}
+
+// extendedResourceReason explains why a single extended resource cannot be satisfied by
+// any candidate node. It returns false when at least one node has enough free capacity
+// for that resource.
+func extendedResourceReason(resourceType string, name corev1.ResourceName, required resource.Quantity,
+	candidates []types.NodeInfo) (types.ReasonDetail, string, bool) {
+	maxAllocatable, maxFree := maxExtendedResource(candidates, name)
+
+	switch {
+	case maxAllocatable.IsZero():
+		return insufficientResourceDetail(resourceType, types.ReasonScopeAllocatable, name, required, maxAllocatable),
+			fmt.Sprintf("Add nodes that provide %s or remove %s.%s", name, resourceType, name), true
+	case required.Cmp(maxAllocatable) > 0:
+		return insufficientResourceDetail(resourceType, types.ReasonScopeAllocatable, name, required, maxAllocatable),
+			fmt.Sprintf("Lower %s.%s to <= %s or add nodes with more %s",
+				resourceType, name, maxAllocatable.String(), name), true
+	case required.Cmp(maxFree) > 0:
+		return insufficientResourceDetail(resourceType, types.ReasonScopeFree, name, required, maxFree),
+			fmt.Sprintf("Lower %s.%s to <= %s, scale down other workloads, or add nodes",
+				resourceType, name, maxFree.String()), true
+	}
+
+	return types.ReasonDetail{}, "", false
+}
+
+// maxExtendedResource returns the largest allocatable and free amounts of a resource across
+// the candidate nodes.
+func maxExtendedResource(candidates []types.NodeInfo, name corev1.ResourceName) (resource.Quantity, resource.Quantity) {
+	var maxAllocatable, maxFree resource.Quantity
+	for _, node := range candidates {
+		if allocatable := node.Allocatable[name]; allocatable.Cmp(maxAllocatable) > 0 {
+			maxAllocatable = allocatable.DeepCopy()
+		}
+		if free := freeResource(node, name); free.Cmp(maxFree) > 0 {
+			maxFree = free
+		}
+	}
+	return maxAllocatable, maxFree
+}
+
+// anyNodeFitsCPUAndMemory reports whether at least one node has enough free CPU and memory
+// for the pod, regardless of its other resources.
+func anyNodeFitsCPUAndMemory(nodeFits []types.NodeFit) bool {
+	for _, nodeFit := range nodeFits {
+		if !containsString(nodeFit.InsufficientResources, string(corev1.ResourceCPU)) &&
+			!containsString(nodeFit.InsufficientResources, string(corev1.ResourceMemory)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestExtendedRequirements(t *testing.T) {
+	pod := types.PodInfo{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse("1"),
+			corev1.ResourceMemory:           resource.MustParse("1Gi"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+			"nvidia.com/gpu":                resource.MustParse("1"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceEphemeralStorage: resource.MustParse("4Gi"),
+		},
+	}
+
+	requests := extendedRequirements(pod, false)
+	assert.Equal(t, []corev1.ResourceName{corev1.ResourceEphemeralStorage, "nvidia.com/gpu"}, sortedResourceNames(requests))
+	assert.True(t, resource.MustParse("1Gi").Equal(requests[corev1.ResourceEphemeralStorage]))
+
+	limits := extendedRequirements(pod, true)
+	assert.True(t, resource.MustParse("4Gi").Equal(limits[corev1.ResourceEphemeralStorage]))
+	assert.True(t, resource.MustParse("1").Equal(limits["nvidia.com/gpu"]))
+
+	assert.Nil(t, extendedRequirements(types.PodInfo{}, false))
+}
+
+func TestAnalyzeSinglePod_ExtendedResources(t *testing.T) {
+	gpuPod := types.PodInfo{
+		Name:           "gpu-pod",
+		Namespace:      "default",
+		RequestsCPU:    resource.MustParse("1"),
+		RequestsMemory: resource.MustParse("8Gi"),
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("8Gi"),
+			"nvidia.com/gpu":      resource.MustParse("1"),
+		},
+	}
+
+	tests := []struct {
+		name               string
+		pod                types.PodInfo
+		nodes              []types.NodeInfo
+		expectSchedulable  bool
+		expectedReason     string
+		expectedSuggestion string
+	}{
+		{
+			name: "gpu available",
+			pod:  gpuPod,
+			nodes: []types.NodeInfo{
+				{
+					Name:              "gpu-node",
+					AllocatableCPU:    resource.MustParse("8"),
+					AllocatableMemory: resource.MustParse("32Gi"),
+					Allocatable:       corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("2")},
+				},
+			},
+			expectSchedulable: true,
+		},
+		{
+			name: "no node provides gpu",
+			pod:  gpuPod,
+			nodes: []types.NodeInfo{
+				{
+					Name:              "cpu-node",
+					AllocatableCPU:    resource.MustParse("8"),
+					AllocatableMemory: resource.MustParse("32Gi"),
+				},
+			},
+			expectedReason:     "requests.nvidia.com/gpu = 1 but no node provides nvidia.com/gpu",
+			expectedSuggestion: "Add nodes that provide nvidia.com/gpu or remove requests.nvidia.com/gpu",
+		},
+		{
+			name: "all gpus in use",
+			pod:  gpuPod,
+			nodes: []types.NodeInfo{
+				{
+					Name:              "gpu-node",
+					AllocatableCPU:    resource.MustParse("8"),
+					AllocatableMemory: resource.MustParse("32Gi"),
+					Allocatable:       corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("2")},
+					Requested:         corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("2")},
+				},
+			},
+			expectedReason:     "requests.nvidia.com/gpu = 1 exceeds the free nvidia.com/gpu left by running pods on all nodes (max free: 0)",
+			expectedSuggestion: "Lower requests.nvidia.com/gpu to <= 0, scale down other workloads, or add nodes",
+		},
+		{
+			name: "gpu and memory on different nodes",
+			pod:  gpuPod,
+			nodes: []types.NodeInfo{
+				{
+					Name:              "small-gpu-node",
+					AllocatableCPU:    resource.MustParse("4"),
+					AllocatableMemory: resource.MustParse("4Gi"),
+					Allocatable:       corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				},
+				{
+					Name:              "large-node",
+					AllocatableCPU:    resource.MustParse("8"),
+					AllocatableMemory: resource.MustParse("32Gi"),
+				},
+			},
+			expectedReason:     "requests.cpu = 1, requests.memory = 8Gi, requests.nvidia.com/gpu = 1 do not fit together on any single node",
+			expectedSuggestion: "Lower the requests so that all of them fit within one node, or add a node with at least cpu=1, memory=8Gi, nvidia.com/gpu=1 free",
+		},
+		{
+			name: "memory and ephemeral-storage both short",
+			pod: types.PodInfo{
+				Name:           "scratch-pod",
+				Namespace:      "default",
+				RequestsCPU:    resource.MustParse("1"),
+				RequestsMemory: resource.MustParse("64Gi"),
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:              resource.MustParse("1"),
+					corev1.ResourceMemory:           resource.MustParse("64Gi"),
+					corev1.ResourceEphemeralStorage: resource.MustParse("200Gi"),
+				},
+			},
+			nodes: []types.NodeInfo{
+				{
+					Name:              "node1",
+					AllocatableCPU:    resource.MustParse("8"),
+					AllocatableMemory: resource.MustParse("32Gi"),
+					Allocatable:       corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("100Gi")},
+				},
+			},
+			expectedReason: "requests.memory = 64Gi exceeds all node allocatable.memory (max: 32Gi); " +
+				"requests.ephemeral-storage = 200Gi exceeds all node allocatable.ephemeral-storage (max: 100Gi)",
+			expectedSuggestion: "Lower requests.memory to <= 32Gi or add higher-memory node; " +
+				"Lower requests.ephemeral-storage to <= 100Gi or add nodes with more ephemeral-storage",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := (&Analyzer{}).analyzeSinglePod(tt.pod, tt.nodes, false)
+
+			assert.Equal(t, tt.expectSchedulable, result.IsSchedulable)
+			assert.Equal(t, tt.expectedReason, result.Reason)
+			assert.Equal(t, tt.expectedSuggestion, result.Suggestion)
+		})
+	}
+}
+
+func TestInsufficientResources(t *testing.T) {
+	node := types.NodeInfo{
+		Name:              "node1",
+		AllocatableCPU:    resource.MustParse("4"),
+		AllocatableMemory: resource.MustParse("8Gi"),
+		Allocatable:       corev1.ResourceList{"hugepages-2Mi": resource.MustParse("1Gi")},
+		Requested:         corev1.ResourceList{"hugepages-2Mi": resource.MustParse("512Mi")},
+	}
+
+	assert.Nil(t, insufficientResources(node, resource.MustParse("1"), resource.MustParse("1Gi"),
+		corev1.ResourceList{"hugepages-2Mi": resource.MustParse("512Mi")}))
+	assert.Equal(t, []string{"cpu", "hugepages-2Mi"}, insufficientResources(node, resource.MustParse("8"), resource.MustParse("1Gi"),
+		corev1.ResourceList{"hugepages-2Mi": resource.MustParse("1Gi")}))
+}
@@ -0,0 +1,312 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// The kube-scheduler Filter plugins whose checks the analyzer reproduces. A scheduler profile
+// that disables one of them also skips the analyzer's corresponding filter.
+const (
+	nodeUnschedulablePlugin = "NodeUnschedulable"
+	nodeAffinityPlugin      = "NodeAffinity"
+	taintTolerationPlugin   = "TaintToleration"
+	volumeBindingPlugin     = "VolumeBinding"
+	nodeVolumeLimitsPlugin  = "NodeVolumeLimits"
+	nodePortsPlugin         = "NodePorts"
+	interPodAffinityPlugin  = "InterPodAffinity"
+	podTopologySpreadPlugin = "PodTopologySpread"
+	nodeResourcesFitPlugin  = "NodeResourcesFit"
+)
+
+// schedulerConfigAPIVersion and schedulerConfigKind identify a KubeSchedulerConfiguration file.
+const (
+	schedulerConfigAPIVersion = "kubescheduler.config.k8s.io/v1"
+	schedulerConfigKind       = "KubeSchedulerConfiguration"
+)
+
+// evaluatedFilterPlugins lists the Filter plugins the analyzer reproduces. All of them are
+// enabled in the scheduler's default profile.
+var evaluatedFilterPlugins = []string{
+	nodeUnschedulablePlugin,
+	nodeAffinityPlugin,
+	taintTolerationPlugin,
+	volumeBindingPlugin,
+	nodeVolumeLimitsPlugin,
+	nodePortsPlugin,
+	interPodAffinityPlugin,
+	podTopologySpreadPlugin,
+	nodeResourcesFitPlugin,
+}
+
+// SchedulerConfig holds the scheduler profiles of a KubeSchedulerConfiguration file: for each
+// schedulerName, which of the evaluated Filter plugins are disabled and which extended
+// resources NodeResourcesFit ignores. Pods whose scheduler has no profile in the file are
+// evaluated with the scheduler defaults.
+type SchedulerConfig struct {
+	profiles map[string]*schedulerProfile
+}
+
+// schedulerProfile is the part of a scheduler profile the analyzer applies.
+type schedulerProfile struct {
+	disabledFilters       map[string]bool
+	ignoredResources      map[corev1.ResourceName]bool
+	ignoredResourceGroups map[string]bool
+}
+
+// kubeSchedulerConfiguration is the subset of the KubeSchedulerConfiguration file format read
+// by ParseSchedulerConfig.
+type kubeSchedulerConfiguration struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Profiles   []struct {
+		SchedulerName string `yaml:"schedulerName"`
+		Plugins       struct {
+			MultiPoint pluginSet `yaml:"multiPoint"`
+			Filter     pluginSet `yaml:"filter"`
+		} `yaml:"plugins"`
+		PluginConfig []struct {
+			Name string    `yaml:"name"`
+			Args yaml.Node `yaml:"args"`
+		} `yaml:"pluginConfig"`
+	} `yaml:"profiles"`
+}
+
+// pluginSet lists the plugins enabled and disabled at one extension point.
+type pluginSet struct {
+	Enabled  []pluginRef `yaml:"enabled"`
+	Disabled []pluginRef `yaml:"disabled"`
+}
+
+// pluginRef names a plugin in a pluginSet.
+type pluginRef struct {
+	Name string `yaml:"name"`
+}
+
+// nodeResourcesFitArgs is the subset of the NodeResourcesFit plugin arguments the analyzer applies.
+type nodeResourcesFitArgs struct {
+	IgnoredResources      []string `yaml:"ignoredResources"`
+	IgnoredResourceGroups []string `yaml:"ignoredResourceGroups"`
+}
+
+// LoadSchedulerConfig reads a KubeSchedulerConfiguration file and parses it with
+// ParseSchedulerConfig.
+//
+// Parameters:
+//   - path: The path of the KubeSchedulerConfiguration file
+//
+// Returns:
+//   - *SchedulerConfig: The parsed scheduler profiles
+//   - error: An error if the file cannot be read or is not a valid configuration
+func LoadSchedulerConfig(path string) (*SchedulerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scheduler config: %w", err)
+	}
+
+	config, err := ParseSchedulerConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse scheduler config %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// ParseSchedulerConfig parses a kubescheduler.config.k8s.io/v1 KubeSchedulerConfiguration.
+// For every profile, the Filter plugins disabled under plugins.multiPoint and plugins.filter
+// ("*" disables all default plugins) are recorded, as are the ignoredResources and
+// ignoredResourceGroups of the NodeResourcesFit plugin arguments. A profile without a
+// schedulerName configures the default scheduler.
+//
+// Parameters:
+//   - data: The contents of the configuration file
+//
+// Returns:
+//   - *SchedulerConfig: The parsed scheduler profiles
+//   - error: An error if the data is not a valid configuration
+func ParseSchedulerConfig(data []byte) (*SchedulerConfig, error) {
+	var file kubeSchedulerConfiguration
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode configuration: %w", err)
+	}
+	if file.APIVersion != schedulerConfigAPIVersion || file.Kind != schedulerConfigKind {
+		return nil, fmt.Errorf("unsupported configuration %s %s (supported: %s %s)",
+			file.APIVersion, file.Kind, schedulerConfigAPIVersion, schedulerConfigKind)
+	}
+
+	config := &SchedulerConfig{profiles: make(map[string]*schedulerProfile)}
+	for _, entry := range file.Profiles {
+		name := entry.SchedulerName
+		if name == "" {
+			name = corev1.DefaultSchedulerName
+		}
+		if _, exists := config.profiles[name]; exists {
+			return nil, fmt.Errorf("duplicate profile for scheduler %q", name)
+		}
+
+		enabled := make(map[string]bool, len(evaluatedFilterPlugins))
+		for _, plugin := range evaluatedFilterPlugins {
+			enabled[plugin] = true
+		}
+		for _, set := range []pluginSet{entry.Plugins.MultiPoint, entry.Plugins.Filter} {
+			for _, plugin := range set.Disabled {
+				if plugin.Name == "*" {
+					enabled = make(map[string]bool)
+					continue
+				}
+				delete(enabled, plugin.Name)
+			}
+			for _, plugin := range set.Enabled {
+				enabled[plugin.Name] = true
+			}
+		}
+
+		profile := &schedulerProfile{
+			disabledFilters:       make(map[string]bool),
+			ignoredResources:      make(map[corev1.ResourceName]bool),
+			ignoredResourceGroups: make(map[string]bool),
+		}
+		for _, plugin := range evaluatedFilterPlugins {
+			if !enabled[plugin] {
+				profile.disabledFilters[plugin] = true
+			}
+		}
+		for plugin := range enabled {
+			if isEvaluatedFilterPlugin(plugin) {
+				continue
+			}
+			logrus.WithFields(logrus.Fields{
+				"scheduler_name": name,
+				"plugin":         plugin,
+			}).Warn("Scheduler profile enables a plugin the analyzer does not evaluate")
+		}
+
+		for _, pluginConfig := range entry.PluginConfig {
+			if pluginConfig.Name != nodeResourcesFitPlugin || pluginConfig.Args.IsZero() {
+				continue
+			}
+			var args nodeResourcesFitArgs
+			if err := pluginConfig.Args.Decode(&args); err != nil {
+				return nil, fmt.Errorf("failed to decode %s args of scheduler %q: %w", nodeResourcesFitPlugin, name, err)
+			}
+			for _, ignored := range args.IgnoredResources {
+				profile.ignoredResources[corev1.ResourceName(ignored)] = true
+			}
+			for _, group := range args.IgnoredResourceGroups {
+				if strings.Contains(group, "/") {
+					return nil, fmt.Errorf("invalid %s ignoredResourceGroups entry %q of scheduler %q: must not contain '/'",
+						nodeResourcesFitPlugin, group, name)
+				}
+				profile.ignoredResourceGroups[group] = true
+			}
+		}
+
+		config.profiles[name] = profile
+	}
+
+	return config, nil
+}
+
+// isEvaluatedFilterPlugin reports whether the analyzer reproduces the plugin's checks.
+func isEvaluatedFilterPlugin(plugin string) bool {
+	for _, evaluated := range evaluatedFilterPlugins {
+		if plugin == evaluated {
+			return true
+		}
+	}
+	return false
+}
+
+// filterEnabled reports whether the profile runs the Filter plugin. A nil profile stands for
+// the scheduler defaults, which run every evaluated plugin.
+func (p *schedulerProfile) filterEnabled(plugin string) bool {
+	return p == nil || !p.disabledFilters[plugin]
+}
+
+// ignoresResource reports whether NodeResourcesFit skips the resource, either by name or by
+// the domain prefix of its name. As in the scheduler, only extended resources, i.e. names with
+// a domain outside kubernetes.io, can be ignored.
+func (p *schedulerProfile) ignoresResource(name corev1.ResourceName) bool {
+	if p == nil {
+		return false
+	}
+	group, _, found := strings.Cut(string(name), "/")
+	if !found || strings.Contains(string(name), corev1.ResourceDefaultNamespacePrefix) {
+		return false
+	}
+	return p.ignoredResources[name] || p.ignoredResourceGroups[group]
+}
+
+// SetSchedulerConfig makes the analyzer apply the scheduler profiles of a
+// KubeSchedulerConfiguration: each pod is evaluated only with the filters its scheduler's
+// profile enables, and without the extended resources that profile ignores.
+//
+// Parameters:
+//   - config: The parsed scheduler configuration, or nil for the scheduler defaults
+func (a *Analyzer) SetSchedulerConfig(config *SchedulerConfig) {
+	a.schedulerConfig = config
+}
+
+// profileFor returns the profile of the scheduler the pod names, or nil when no
+// configuration is set or it has no profile for that scheduler.
+func (a *Analyzer) profileFor(pod types.PodInfo) *schedulerProfile {
+	if a.schedulerConfig == nil {
+		return nil
+	}
+	name := pod.SchedulerName
+	if name == "" {
+		name = corev1.DefaultSchedulerName
+	}
+	return a.schedulerConfig.profiles[name]
+}
+
+// runFilter applies a filter to the nodes unless the pod's scheduler profile disables the
+// Filter plugin it reproduces, in which case every node is kept.
+func (a *Analyzer) runFilter(pod types.PodInfo, plugin string, nodes []types.NodeInfo,
+	filter func(types.PodInfo, []types.NodeInfo) ([]types.NodeInfo, nodeExclusion)) ([]types.NodeInfo, nodeExclusion) {
+	if !a.profileFor(pod).filterEnabled(plugin) {
+		return nodes, nodeExclusion{}
+	}
+	return filter(pod, nodes)
+}
+
+// requirements returns the pod's CPU, memory and extended resource requirements as its
+// scheduler profile checks them: extended resources NodeResourcesFit ignores are dropped, and
+// a profile without NodeResourcesFit checks no resources at all.
+//
+// Parameters:
+//   - pod: The pod whose requirements are returned
+//   - includeLimits: If true, prefers limits over requests
+//
+// Returns:
+//   - resource.Quantity: The CPU requirement
+//   - resource.Quantity: The memory requirement
+//   - string: Either "requests" or "limits", used in the wording of reasons
+//   - corev1.ResourceList: The extended resource requirements, or nil if there are none
+func (a *Analyzer) requirements(pod types.PodInfo,
+	includeLimits bool) (resource.Quantity, resource.Quantity, string, corev1.ResourceList) {
+	podCPU, podMemory, resourceType := podRequirements(pod, includeLimits)
+	extended := extendedRequirements(pod, includeLimits)
+
+	profile := a.profileFor(pod)
+	if !profile.filterEnabled(nodeResourcesFitPlugin) {
+		return resource.Quantity{}, resource.Quantity{}, resourceType, nil
+	}
+	for name := range extended {
+		if profile.ignoresResource(name) {
+			delete(extended, name)
+		}
+	}
+	if len(extended) == 0 {
+		extended = nil
+	}
+
+	return podCPU, podMemory, resourceType, extended
+}
@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const testSchedulerConfig = `apiVersion: kubescheduler.config.k8s.io/v1
+kind: KubeSchedulerConfiguration
+profiles:
+  - plugins:
+      filter:
+        disabled:
+          - name: TaintToleration
+  - schedulerName: accelerator-scheduler
+    plugins:
+      multiPoint:
+        disabled:
+          - name: "*"
+        enabled:
+          - name: NodeAffinity
+          - name: NodeResourcesFit
+          - name: CustomFilter
+    pluginConfig:
+      - name: NodeResourcesFit
+        args:
+          ignoredResources:
+            - example.com/fpga
+          ignoredResourceGroups:
+            - vendor.io
+`
+
+func TestParseSchedulerConfig(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             string
+		expectedProfiles map[string]*schedulerProfile
+		expectedError    string
+	}{
+		{
+			name: "profiles with disabled filters and ignored resources",
+			data: testSchedulerConfig,
+			expectedProfiles: map[string]*schedulerProfile{
+				"default-scheduler": {
+					disabledFilters:       map[string]bool{taintTolerationPlugin: true},
+					ignoredResources:      map[corev1.ResourceName]bool{},
+					ignoredResourceGroups: map[string]bool{},
+				},
+				"accelerator-scheduler": {
+					disabledFilters: map[string]bool{
+						nodeUnschedulablePlugin: true,
+						taintTolerationPlugin:   true,
+						volumeBindingPlugin:     true,
+						nodeVolumeLimitsPlugin:  true,
+						nodePortsPlugin:         true,
+						interPodAffinityPlugin:  true,
+						podTopologySpreadPlugin: true,
+					},
+					ignoredResources:      map[corev1.ResourceName]bool{"example.com/fpga": true},
+					ignoredResourceGroups: map[string]bool{"vendor.io": true},
+				},
+			},
+		},
+		{
+			name:             "no profiles",
+			data:             "apiVersion: kubescheduler.config.k8s.io/v1\nkind: KubeSchedulerConfiguration\n",
+			expectedProfiles: map[string]*schedulerProfile{},
+		},
+		{
+			name:          "unsupported kind",
+			data:          "apiVersion: kubescheduler.config.k8s.io/v1\nkind: Policy\n",
+			expectedError: "unsupported configuration kubescheduler.config.k8s.io/v1 Policy",
+		},
+		{
+			name:          "unsupported api version",
+			data:          "apiVersion: kubescheduler.config.k8s.io/v1beta2\nkind: KubeSchedulerConfiguration\n",
+			expectedError: "unsupported configuration kubescheduler.config.k8s.io/v1beta2 KubeSchedulerConfiguration",
+		},
+		{
+			name: "duplicate profile",
+			data: "apiVersion: kubescheduler.config.k8s.io/v1\nkind: KubeSchedulerConfiguration\n" +
+				"profiles:\n  - schedulerName: default-scheduler\n  - {}\n",
+			expectedError: `duplicate profile for scheduler "default-scheduler"`,
+		},
+		{
+			name: "resource group with a slash",
+			data: "apiVersion: kubescheduler.config.k8s.io/v1\nkind: KubeSchedulerConfiguration\n" +
+				"profiles:\n  - pluginConfig:\n      - name: NodeResourcesFit\n" +
+				"        args:\n          ignoredResourceGroups: [example.com/fpga]\n",
+			expectedError: `invalid NodeResourcesFit ignoredResourceGroups entry "example.com/fpga"`,
+		},
+		{
+			name:          "invalid yaml",
+			data:          "profiles: [",
+			expectedError: "failed to decode configuration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseSchedulerConfig([]byte(tt.data))
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedProfiles, config.profiles)
+		})
+	}
+}
+
+func TestLoadSchedulerConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduler-config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testSchedulerConfig), 0o600))
+
+	config, err := LoadSchedulerConfig(path)
+
+	require.NoError(t, err)
+	assert.Len(t, config.profiles, 2)
+
+	_, err = LoadSchedulerConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read scheduler config")
+}
+
+func TestSchedulerProfileIgnoresResource(t *testing.T) {
+	profile := &schedulerProfile{
+		ignoredResources:      map[corev1.ResourceName]bool{"example.com/fpga": true, "cpu": true},
+		ignoredResourceGroups: map[string]bool{"vendor.io": true, "hugepages-2Mi": true},
+	}
+
+	tests := []struct {
+		name     corev1.ResourceName
+		profile  *schedulerProfile
+		expected bool
+	}{
+		{name: "example.com/fpga", profile: profile, expected: true},
+		{name: "vendor.io/gpu", profile: profile, expected: true},
+		{name: "example.com/gpu", profile: profile},
+		{name: "cpu", profile: profile},
+		{name: "hugepages-2Mi", profile: profile},
+		{name: "example.com/fpga"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.name), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.profile.ignoresResource(tt.name))
+		})
+	}
+}
+
+func TestAnalyzeSinglePod_SchedulerProfile(t *testing.T) {
+	config, err := ParseSchedulerConfig([]byte(testSchedulerConfig +
+		"  - schedulerName: unchecked-scheduler\n    plugins:\n      filter:\n        disabled:\n" +
+		"          - name: NodeResourcesFit\n          - name: TaintToleration\n"))
+	require.NoError(t, err)
+
+	tainted := hostnameNode("node-1", "a")
+	tainted.Taints = []corev1.Taint{{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}}
+	pod := func(schedulerName string, requests corev1.ResourceList) types.PodInfo {
+		return types.PodInfo{
+			Name:           "app",
+			Namespace:      "default",
+			SchedulerName:  schedulerName,
+			RequestsCPU:    requests[corev1.ResourceCPU],
+			RequestsMemory: resource.MustParse("1Gi"),
+			Requests:       requests,
+		}
+	}
+
+	tests := []struct {
+		name             string
+		config           *SchedulerConfig
+		pod              types.PodInfo
+		expectedFits     bool
+		expectedSummary  string
+		expectedNodeFits int
+	}{
+		{
+			name:             "taint enforced without a configuration",
+			pod:              pod("", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}),
+			expectedSummary:  "0/1 nodes are available: 1 node(s) had untolerated taint {dedicated: batch}.",
+			expectedNodeFits: 0,
+		},
+		{
+			name:             "taint ignored by the default profile",
+			config:           config,
+			pod:              pod("", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}),
+			expectedFits:     true,
+			expectedNodeFits: 1,
+		},
+		{
+			name:   "ignored extended resource",
+			config: config,
+			pod: pod("accelerator-scheduler", corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("1"),
+				"example.com/fpga": resource.MustParse("1"),
+			}),
+			expectedFits:     true,
+			expectedNodeFits: 1,
+		},
+		{
+			name:   "extended resource outside the ignored ones",
+			config: config,
+			pod: pod("accelerator-scheduler", corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("1"),
+				"example.com/gpu":  resource.MustParse("1"),
+			}),
+			expectedSummary:  "0/1 nodes are available: 1 Insufficient example.com/gpu.",
+			expectedNodeFits: 1,
+		},
+		{
+			name:             "resources unchecked without NodeResourcesFit",
+			config:           config,
+			pod:              pod("unchecked-scheduler", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("64")}),
+			expectedFits:     true,
+			expectedNodeFits: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &Analyzer{knownSchedulers: map[string]bool{
+				"accelerator-scheduler": true,
+				"unchecked-scheduler":   true,
+			}}
+			analyzer.SetSchedulerConfig(tt.config)
+
+			result := analyzer.analyzeSinglePod(tt.pod, []types.NodeInfo{tainted}, false)
+
+			assert.Equal(t, tt.expectedFits, result.IsSchedulable, result.Reason)
+			assert.Equal(t, tt.expectedSummary, result.RejectionSummary)
+			assert.Len(t, result.Nodes, tt.expectedNodeFits)
+		})
+	}
+}
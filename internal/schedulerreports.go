@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// schedulerReportCondition marks reports taken from the PodScheduled condition.
+	schedulerReportCondition = "PodScheduled condition"
+	// schedulerReportEvent marks reports taken from a FailedScheduling event.
+	schedulerReportEvent = "FailedScheduling event"
+)
+
+// availabilityMessage matches the "0/N nodes are available: ..." summary the scheduler
+// writes, up to the optional preemption part.
+var availabilityMessage = regexp.MustCompile(`^0/(\d+) nodes are available: (.*?)\.?(?: preemption: .*)?$`)
+
+// nodeReasonItem matches one "<count> <reason>" item of the availability summary.
+var nodeReasonItem = regexp.MustCompile(`^(\d+) (.+)$`)
+
+// schedulerReports collects what the scheduler itself reported for a pending pod: the
+// message of a PodScheduled=False condition and the latest FailedScheduling event.
+//
+// Parameters:
+//   - pod: The pending pod
+//   - event: The pod's latest FailedScheduling event, or nil if there is none
+//
+// Returns:
+//   - []types.SchedulerReport: The parsed reports, condition first
+func schedulerReports(pod corev1.Pod, event *corev1.Event) []types.SchedulerReport {
+	var reports []types.SchedulerReport
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != corev1.PodScheduled || condition.Status != corev1.ConditionFalse {
+			continue
+		}
+		reports = append(reports, newSchedulerReport(schedulerReportCondition, condition.Reason,
+			condition.Message, condition.LastTransitionTime.Time))
+	}
+
+	if event != nil {
+		reports = append(reports, newSchedulerReport(schedulerReportEvent, event.Reason, event.Message, eventTime(*event)))
+	}
+
+	return reports
+}
+
+func newSchedulerReport(source, reason, message string, at time.Time) types.SchedulerReport {
+	report := types.SchedulerReport{Source: source, Reason: reason, Message: message, Time: at}
+	report.TotalNodes, report.NodeReasons = parseAvailabilityMessage(message)
+	return report
+}
+
+// parseAvailabilityMessage splits a scheduler message such as "0/5 nodes are available:
+// 2 Insufficient cpu, 3 node(s) had untolerated taint {dedicated: gpu}." into the number of
+// nodes and the number of nodes per reason. Messages in another form yield zero and nil.
+func parseAvailabilityMessage(message string) (int, map[string]int) {
+	match := availabilityMessage.FindStringSubmatch(strings.TrimSpace(message))
+	if match == nil {
+		return 0, nil
+	}
+
+	total, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, nil
+	}
+
+	reasons := make(map[string]int)
+	for _, item := range strings.Split(match[2], ", ") {
+		parts := nodeReasonItem.FindStringSubmatch(strings.TrimSpace(item))
+		if parts == nil {
+			continue
+		}
+		count, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		reasons[parts[2]] += count
+	}
+
+	return total, reasons
+}
+
+// eventTime returns when an event was last observed.
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.FirstTimestamp.Time
+	}
+}
+
+// schedulerDisagreement compares the analysis verdict with the scheduler's own reports and
+// explains where they differ. When both find the pod unschedulable, the number of nodes the
+// scheduler rejected for each reason is compared with the analysis' node rejections. The
+// scheduler's reports can be older than the cluster state the analysis saw, and it may
+// evaluate constraints the analysis does not model.
+//
+// Parameters:
+//   - result: The analysis result, including the pod's scheduler reports and node rejections
+//   - totalNodes: The number of nodes the analysis evaluated
+//
+// Returns:
+//   - string: The disagreement, or an empty string if both agree
+func schedulerDisagreement(result types.AnalysisResult, totalNodes int) string {
+	reports := result.Pod.SchedulerReports
+	if len(reports) == 0 {
+		return ""
+	}
+	latest := reports[0]
+	for _, report := range reports[1:] {
+		if report.Time.After(latest.Time) {
+			latest = report
+		}
+	}
+
+	if result.IsSchedulable && latest.Reason != string(corev1.PodReasonSchedulingGated) {
+		return fmt.Sprintf("the scheduler reports the pod as unschedulable (%s: %s) although the analysis found "+
+			"fitting node(s) %s; the scheduler may evaluate constraints not modelled here, or the cluster changed "+
+			"since its last attempt", latest.Source, latest.Message, strings.Join(result.FittingNodes, ", "))
+	}
+
+	if latest.TotalNodes > 0 && latest.TotalNodes != totalNodes {
+		return fmt.Sprintf("the scheduler evaluated %d node(s) (%s) but the analysis saw %d",
+			latest.TotalNodes, latest.Source, totalNodes)
+	}
+
+	if !result.IsSchedulable && len(latest.NodeReasons) > 0 && len(result.NodeRejections) > 0 {
+		mismatches := reasonCountMismatches(latest.NodeReasons, rejectionCounts(result.NodeRejections))
+		if len(mismatches) > 0 {
+			return fmt.Sprintf("both find the pod unschedulable, but the scheduler (%s) and the analysis reject nodes "+
+				"for different reasons: %s", latest.Source, strings.Join(mismatches, "; "))
+		}
+	}
+
+	return ""
+}
+
+// reasonCountMismatches lists, sorted by reason, the reasons for which the scheduler and the
+// analysis rejected a different number of nodes.
+func reasonCountMismatches(scheduler, analysis map[string]int) []string {
+	reasons := make([]string, 0, len(scheduler)+len(analysis))
+	for reason := range scheduler {
+		reasons = append(reasons, reason)
+	}
+	for reason := range analysis {
+		if _, ok := scheduler[reason]; !ok {
+			reasons = append(reasons, reason)
+		}
+	}
+	sort.Strings(reasons)
+
+	var mismatches []string
+	for _, reason := range reasons {
+		if scheduler[reason] != analysis[reason] {
+			mismatches = append(mismatches, fmt.Sprintf("%q: scheduler %d node(s), analysis %d",
+				reason, scheduler[reason], analysis[reason]))
+		}
+	}
+	return mismatches
+}
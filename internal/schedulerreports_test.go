@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseAvailabilityMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		message         string
+		expectedTotal   int
+		expectedReasons map[string]int
+	}{
+		{
+			name:          "resources and taints",
+			message:       "0/5 nodes are available: 2 Insufficient cpu, 3 node(s) had untolerated taint {dedicated: gpu}.",
+			expectedTotal: 5,
+			expectedReasons: map[string]int{
+				"Insufficient cpu": 2,
+				"node(s) had untolerated taint {dedicated: gpu}": 3,
+			},
+		},
+		{
+			name: "with preemption part",
+			message: "0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector. " +
+				"preemption: 0/3 nodes are available: 3 Preemption is not helpful for scheduling.",
+			expectedTotal:   3,
+			expectedReasons: map[string]int{"node(s) didn't match Pod's node affinity/selector": 3},
+		},
+		{
+			name:    "other message",
+			message: "running PreBind plugin \"VolumeBinding\": binding volumes: timed out waiting for the condition",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, reasons := parseAvailabilityMessage(tt.message)
+
+			assert.Equal(t, tt.expectedTotal, total)
+			assert.Equal(t, tt.expectedReasons, reasons)
+		})
+	}
+}
+
+func TestSchedulerReports(t *testing.T) {
+	conditionTime := metav1.NewTime(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	eventTime := metav1.NewTime(time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC))
+	pod := corev1.Pod{
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionFalse},
+				{
+					Type:               corev1.PodScheduled,
+					Status:             corev1.ConditionFalse,
+					Reason:             corev1.PodReasonUnschedulable,
+					Message:            "0/2 nodes are available: 2 Insufficient memory.",
+					LastTransitionTime: conditionTime,
+				},
+			},
+		},
+	}
+	event := &corev1.Event{
+		Reason:        "FailedScheduling",
+		Message:       "0/2 nodes are available: 2 Insufficient memory.",
+		LastTimestamp: eventTime,
+	}
+
+	reports := schedulerReports(pod, event)
+
+	assert.Equal(t, []types.SchedulerReport{
+		{
+			Source:      schedulerReportCondition,
+			Reason:      "Unschedulable",
+			Message:     "0/2 nodes are available: 2 Insufficient memory.",
+			Time:        conditionTime.Time,
+			TotalNodes:  2,
+			NodeReasons: map[string]int{"Insufficient memory": 2},
+		},
+		{
+			Source:      schedulerReportEvent,
+			Reason:      "FailedScheduling",
+			Message:     "0/2 nodes are available: 2 Insufficient memory.",
+			Time:        eventTime.Time,
+			TotalNodes:  2,
+			NodeReasons: map[string]int{"Insufficient memory": 2},
+		},
+	}, reports)
+}
+
+func TestSchedulerDisagreement(t *testing.T) {
+	report := types.SchedulerReport{
+		Source:      schedulerReportEvent,
+		Reason:      "FailedScheduling",
+		Message:     "0/3 nodes are available: 3 Insufficient cpu.",
+		TotalNodes:  3,
+		NodeReasons: map[string]int{"Insufficient cpu": 3},
+	}
+	rejections := func(reasons ...string) []types.NodeRejection {
+		var nodeRejections []types.NodeRejection
+		for i, reason := range reasons {
+			nodeRejections = append(nodeRejections,
+				types.NodeRejection{Node: fmt.Sprintf("node-%d", i+1), Reasons: []string{reason}})
+		}
+		return nodeRejections
+	}
+
+	tests := []struct {
+		name       string
+		result     types.AnalysisResult
+		totalNodes int
+		expected   string
+	}{
+		{
+			name:       "no scheduler report",
+			result:     types.AnalysisResult{IsSchedulable: true, FittingNodes: []string{"node-1"}},
+			totalNodes: 3,
+		},
+		{
+			name: "analysis finds a node the scheduler rejected",
+			result: types.AnalysisResult{
+				Pod:           types.PodInfo{SchedulerReports: []types.SchedulerReport{report}},
+				IsSchedulable: true,
+				FittingNodes:  []string{"node-1"},
+			},
+			totalNodes: 3,
+			expected: "the scheduler reports the pod as unschedulable (FailedScheduling event: 0/3 nodes are available: " +
+				"3 Insufficient cpu.) although the analysis found fitting node(s) node-1; the scheduler may evaluate " +
+				"constraints not modelled here, or the cluster changed since its last attempt",
+		},
+		{
+			name: "both find the pod unschedulable",
+			result: types.AnalysisResult{
+				Pod: types.PodInfo{SchedulerReports: []types.SchedulerReport{report}},
+			},
+			totalNodes: 3,
+		},
+		{
+			name: "same reasons for the same nodes",
+			result: types.AnalysisResult{
+				Pod:            types.PodInfo{SchedulerReports: []types.SchedulerReport{report}},
+				NodeRejections: rejections("Insufficient cpu", "Insufficient cpu", "Insufficient cpu"),
+			},
+			totalNodes: 3,
+		},
+		{
+			name: "different reasons although both find the pod unschedulable",
+			result: types.AnalysisResult{
+				Pod: types.PodInfo{SchedulerReports: []types.SchedulerReport{report}},
+				NodeRejections: rejections("Insufficient cpu", "Insufficient cpu",
+					"node(s) had untolerated taint {dedicated: gpu}"),
+			},
+			totalNodes: 3,
+			expected: "both find the pod unschedulable, but the scheduler (FailedScheduling event) and the analysis " +
+				`reject nodes for different reasons: "Insufficient cpu": scheduler 3 node(s), analysis 2; ` +
+				`"node(s) had untolerated taint {dedicated: gpu}": scheduler 0 node(s), analysis 1`,
+		},
+		{
+			name: "different node counts",
+			result: types.AnalysisResult{
+				Pod: types.PodInfo{SchedulerReports: []types.SchedulerReport{report}},
+			},
+			totalNodes: 5,
+			expected:   "the scheduler evaluated 3 node(s) (FailedScheduling event) but the analysis saw 5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, schedulerDisagreement(tt.result, tt.totalNodes))
+		})
+	}
+}
+
+func TestAnalyzeSinglePod_SchedulerReasonMismatch(t *testing.T) {
+	node := hostnameNode("node-1", "a")
+	node.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+	pod := types.PodInfo{
+		Name:      "web-0",
+		Namespace: "default",
+		SchedulerReports: []types.SchedulerReport{{
+			Source:      schedulerReportEvent,
+			Reason:      "FailedScheduling",
+			Message:     "0/1 nodes are available: 1 Insufficient cpu.",
+			TotalNodes:  1,
+			NodeReasons: map[string]int{"Insufficient cpu": 1},
+		}},
+	}
+
+	result := (&Analyzer{}).analyzeSinglePod(pod, []types.NodeInfo{node}, false)
+
+	assert.False(t, result.IsSchedulable)
+	assert.Equal(t, "0/1 nodes are available: 1 node(s) had untolerated taint {dedicated: gpu}.", result.RejectionSummary)
+	assert.Contains(t, result.SchedulerDisagreement, `"Insufficient cpu": scheduler 1 node(s), analysis 0`)
+}
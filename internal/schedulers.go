@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// servedSchedulers returns the scheduler names known to be served: the default scheduler
+// and every scheduler that placed one of the running pods.
+//
+// Parameters:
+//   - scheduledPods: Pods bound to nodes, as returned by FetchScheduledPods
+//
+// Returns:
+//   - map[string]bool: The set of served scheduler names
+func servedSchedulers(scheduledPods []types.PodInfo) map[string]bool {
+	served := map[string]bool{corev1.DefaultSchedulerName: true}
+	for _, pod := range scheduledPods {
+		if pod.SchedulerName != "" {
+			served[pod.SchedulerName] = true
+		}
+	}
+	return served
+}
+
+// schedulingBlocker reports pods that no scheduler will consider regardless of capacity:
+// pods with scheduling gates, and pods naming a scheduler that is not known to run. A
+// scheduler counts as running when it placed a running pod, reported on this pod, has a
+// profile in the scheduler configuration or holds a Lease in kube-system.
+//
+// Parameters:
+//   - pod: The pending pod
+//
+// Returns:
+//   - types.AnalysisResult: The unschedulable result explaining the blocker
+//   - bool: True if the pod is blocked and the fit analysis should be skipped
+func (a *Analyzer) schedulingBlocker(pod types.PodInfo) (types.AnalysisResult, bool) {
+	result := types.AnalysisResult{
+		Pod:              pod,
+		Category:         types.PendingCategoryUnscheduled,
+		SchedulerReports: pod.SchedulerReports,
+	}
+
+	if len(pod.SchedulingGates) > 0 {
+		gates := strings.Join(pod.SchedulingGates, ", ")
+		result.Reasons = []types.ReasonDetail{messageDetail(types.ReasonCodeSchedulingGated,
+			fmt.Sprintf("scheduling gated: the scheduler ignores the pod until its schedulingGates (%s) are removed", gates),
+			nil, pod.SchedulingGates)}
+		result.Reason = renderReasons(result.Reasons)
+		result.Suggestion = fmt.Sprintf("Check the controller responsible for the gate(s) %s, or remove them from spec.schedulingGates",
+			gates)
+		result.SchedulingGates = pod.SchedulingGates
+		return result, true
+	}
+
+	if pod.SchedulerName != "" && !a.schedulerServed(pod) {
+		result.Reasons = []types.ReasonDetail{messageDetail(types.ReasonCodeUnknownScheduler,
+			fmt.Sprintf("schedulerName %q is not served by any running scheduler", pod.SchedulerName),
+			nil, []string{pod.SchedulerName})}
+		result.Reason = renderReasons(result.Reasons)
+		result.Suggestion = fmt.Sprintf("Deploy the scheduler %q, or remove spec.schedulerName to use %s",
+			pod.SchedulerName, corev1.DefaultSchedulerName)
+		result.UnknownScheduler = pod.SchedulerName
+		return result, true
+	}
+
+	return result, false
+}
+
+// schedulerServed reports whether the pod's scheduler is known to run. When no scheduled
+// pods, profiles or Leases were seen, only the default scheduler is known.
+func (a *Analyzer) schedulerServed(pod types.PodInfo) bool {
+	if pod.SchedulerName == corev1.DefaultSchedulerName || a.knownSchedulers[pod.SchedulerName] {
+		return true
+	}
+	if a.schedulerConfig != nil && a.schedulerConfig.profiles[pod.SchedulerName] != nil {
+		return true
+	}
+	for _, report := range pod.SchedulerReports {
+		if report.Reason != string(corev1.PodReasonSchedulingGated) {
+			return true
+		}
+	}
+	return false
+}
+
+// namesUnservedScheduler reports whether any of the pods names a scheduler that is not
+// known to run, so that the scheduler Leases have to be looked up.
+func (a *Analyzer) namesUnservedScheduler(pods []types.PodInfo) bool {
+	for _, pod := range pods {
+		if pod.SchedulerName != "" && len(pod.SchedulingGates) == 0 && !a.schedulerServed(pod) {
+			return true
+		}
+	}
+	return false
+}
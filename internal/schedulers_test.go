@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestSchedulingBlocker(t *testing.T) {
+	tests := []struct {
+		name            string
+		pod             types.PodInfo
+		knownSchedulers map[string]bool
+		config          *SchedulerConfig
+		expectedBlocked bool
+		expectedReason  string
+		expectedGates   []string
+		expectedUnknown string
+	}{
+		{
+			name:            "scheduling gates",
+			pod:             types.PodInfo{SchedulingGates: []string{"example.com/quota", "example.com/provisioning"}},
+			expectedBlocked: true,
+			expectedReason: "scheduling gated: the scheduler ignores the pod until its schedulingGates " +
+				"(example.com/quota, example.com/provisioning) are removed",
+			expectedGates: []string{"example.com/quota", "example.com/provisioning"},
+		},
+		{
+			name:            "unknown scheduler",
+			pod:             types.PodInfo{SchedulerName: "batch-scheduler"},
+			expectedBlocked: true,
+			expectedReason:  `schedulerName "batch-scheduler" is not served by any running scheduler`,
+			expectedUnknown: "batch-scheduler",
+		},
+		{
+			name:            "scheduler that placed running pods",
+			pod:             types.PodInfo{SchedulerName: "batch-scheduler"},
+			knownSchedulers: map[string]bool{"batch-scheduler": true},
+		},
+		{
+			name: "scheduler that reported on the pod",
+			pod: types.PodInfo{
+				SchedulerName:    "batch-scheduler",
+				SchedulerReports: []types.SchedulerReport{{Source: schedulerReportEvent, Reason: "FailedScheduling"}},
+			},
+		},
+		{
+			name:   "scheduler with a configured profile",
+			pod:    types.PodInfo{SchedulerName: "batch-scheduler"},
+			config: &SchedulerConfig{profiles: map[string]*schedulerProfile{"batch-scheduler": {}}},
+		},
+		{
+			name: "default scheduler",
+			pod:  types.PodInfo{SchedulerName: "default-scheduler"},
+		},
+		{
+			name: "no scheduler name",
+			pod:  types.PodInfo{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &Analyzer{knownSchedulers: tt.knownSchedulers, schedulerConfig: tt.config}
+
+			result, blocked := analyzer.schedulingBlocker(tt.pod)
+
+			assert.Equal(t, tt.expectedBlocked, blocked)
+			if !tt.expectedBlocked {
+				return
+			}
+			assert.Equal(t, types.PendingCategoryUnscheduled, result.Category)
+			assert.False(t, result.IsSchedulable)
+			assert.Equal(t, tt.expectedReason, result.Reason)
+			assert.NotEmpty(t, result.Suggestion)
+			assert.Equal(t, tt.expectedGates, result.SchedulingGates)
+			assert.Equal(t, tt.expectedUnknown, result.UnknownScheduler)
+		})
+	}
+}
+
+func TestAnalyzePodSchedulability_SchedulingBlockers(t *testing.T) {
+	small := func(name, schedulerName string, gates ...string) types.PodInfo {
+		return types.PodInfo{
+			Name:            name,
+			Namespace:       "default",
+			SchedulerName:   schedulerName,
+			SchedulingGates: gates,
+			RequestsCPU:     resource.MustParse("100m"),
+			RequestsMemory:  resource.MustParse("128Mi"),
+		}
+	}
+	pods := []types.PodInfo{
+		small("gated", "default-scheduler", "example.com/quota"),
+		small("custom", "batch-scheduler"),
+		small("orphaned", "missing-scheduler"),
+		small("elected", "lease-scheduler"),
+	}
+	scheduled := []types.PodInfo{{Name: "job-0", Namespace: "default", NodeName: "node-1", SchedulerName: "batch-scheduler"}}
+	nodes := []types.NodeInfo{hostnameNode("node-1", "a")}
+
+	mockFetcher := &MockFetcher{}
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return(pods, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return(nodes, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return(scheduled, nil)
+	mockFetcher.On("FetchSchedulerLeases", mock.Anything).Return([]string{"kube-controller-manager", "lease-scheduler"}, nil)
+
+	results, err := NewAnalyzer(mockFetcher).AnalyzePodSchedulability(context.Background(), "default", false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.False(t, results[0].IsSchedulable)
+	assert.Equal(t, []string{"example.com/quota"}, results[0].SchedulingGates)
+	assert.Empty(t, results[0].Nodes)
+	assert.True(t, results[1].IsSchedulable)
+	assert.Empty(t, results[1].UnknownScheduler)
+	assert.False(t, results[2].IsSchedulable)
+	assert.Equal(t, "missing-scheduler", results[2].UnknownScheduler)
+	assert.Equal(t, types.ReasonCodeUnknownScheduler, results[2].Reasons[0].Code)
+	assert.Empty(t, results[2].Nodes)
+	assert.False(t, results[2].CollectivelyUnplaced)
+	assert.True(t, results[3].IsSchedulable)
+	mockFetcher.AssertExpectations(t)
+}
+
+func TestAnalyzePodSchedulability_SchedulerLeasesUnavailable(t *testing.T) {
+	pod := types.PodInfo{
+		Name:           "orphaned",
+		Namespace:      "default",
+		SchedulerName:  "missing-scheduler",
+		RequestsCPU:    resource.MustParse("100m"),
+		RequestsMemory: resource.MustParse("128Mi"),
+	}
+
+	mockFetcher := &MockFetcher{}
+	mockFetcher.On("FetchPendingPods", mock.Anything, "default").Return([]types.PodInfo{pod}, nil)
+	mockFetcher.On("FetchNodes", mock.Anything).Return([]types.NodeInfo{hostnameNode("node-1", "a")}, nil)
+	mockFetcher.On("FetchScheduledPods", mock.Anything).Return([]types.PodInfo{}, nil)
+	mockFetcher.On("FetchSchedulerLeases", mock.Anything).
+		Return([]string(nil), errors.New("leases.coordination.k8s.io is forbidden"))
+
+	results, err := NewAnalyzer(mockFetcher).AnalyzePodSchedulability(context.Background(), "default", false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "missing-scheduler", results[0].UnknownScheduler)
+	mockFetcher.AssertExpectations(t)
+}
@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// filterByTopologySpread removes nodes on which the pod would violate a topology spread
+// constraint with whenUnsatisfiable: DoNotSchedule. Matching pods are counted per domain over
+// the nodes that qualify under nodeAffinityPolicy and nodeTaintsPolicy, and a node is excluded
+// when placing the pod there would make the skew against the least populated domain exceed
+// maxSkew. When fewer domains than minDomains exist, the minimum is treated as zero. Nodes
+// missing a topology key are excluded as well. The domain counts are computed once per
+// constraint.
+//
+// Parameters:
+//   - pod: The pod whose topology spread constraints are checked
+//   - candidates: Candidate nodes to filter
+//   - allNodes: Every node in the cluster, with their bound pods attached
+//
+// Returns:
+//   - []types.NodeInfo: Nodes on which every DoNotSchedule constraint is satisfied
+//   - nodeExclusion: The excluded nodes, with the skew-violating domains as details
+func filterByTopologySpread(pod types.PodInfo, candidates, allNodes []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+	exclusion := nodeExclusion{constraint: "topology spread constraints", code: types.ReasonCodeTopologySpreadViolation}
+
+	constraints := hardSpreadConstraints(pod)
+	if len(constraints) == 0 {
+		return candidates, exclusion
+	}
+
+	counts := make([]map[string]int, len(constraints))
+	minCounts := make([]int, len(constraints))
+	for i, constraint := range constraints {
+		counts[i] = spreadCounts(pod, constraint, constraints, allNodes)
+		minCounts[i] = minSpreadCount(counts[i], constraint.MinDomains)
+	}
+
+	kept := make([]types.NodeInfo, 0, len(candidates))
+	violated := make(map[string]bool)
+	leastPopulated := make(map[string]bool)
+	var missingKeys []string
+
+	for _, node := range candidates {
+		fits := true
+		for i, constraint := range constraints {
+			value, ok := node.Labels[constraint.TopologyKey]
+			if !ok {
+				if !containsString(missingKeys, constraint.TopologyKey) {
+					missingKeys = append(missingKeys, constraint.TopologyKey)
+				}
+				fits = false
+				continue
+			}
+
+			domainCounts, minCount := counts[i], minCounts[i]
+			skew := domainCounts[value] + selfMatch(pod, constraint) - minCount
+			if skew <= int(constraint.MaxSkew) {
+				continue
+			}
+
+			fits = false
+			violated[fmt.Sprintf("%s=%s has %d matching pod(s), skew %d > maxSkew %d (min %d)",
+				constraint.TopologyKey, value, domainCounts[value], skew, constraint.MaxSkew, minCount)] = true
+			for domainValue, count := range domainCounts {
+				if count == minCount {
+					leastPopulated[fmt.Sprintf("%s=%s", constraint.TopologyKey, domainValue)] = true
+				}
+			}
+		}
+
+		if fits {
+			kept = append(kept, node)
+		} else {
+			exclusion.exclude(node, "node(s) didn't match pod topology spread constraints")
+		}
+	}
+
+	exclusion.details = sortedKeys(violated)
+	parts := append([]string{}, exclusion.details...)
+	for _, key := range missingKeys {
+		parts = append(parts, fmt.Sprintf("node(s) without label %s", key))
+	}
+
+	exclusion.reason = fmt.Sprintf("all %d node(s) violate topologySpreadConstraints: %s",
+		len(exclusion.nodes), strings.Join(parts, "; "))
+	exclusion.summary = fmt.Sprintf("%d node(s) violate topologySpreadConstraints (%s)",
+		len(exclusion.nodes), strings.Join(parts, "; "))
+	if len(leastPopulated) > 0 {
+		exclusion.suggestion = fmt.Sprintf(
+			"Add capacity in the least populated domain(s) (%s), raise maxSkew, or use whenUnsatisfiable: ScheduleAnyway",
+			strings.Join(sortedKeys(leastPopulated), ", "))
+	} else {
+		exclusion.suggestion = "Label the nodes with the topology keys, raise maxSkew, or use whenUnsatisfiable: ScheduleAnyway"
+	}
+
+	return kept, exclusion
+}
+
+// hardSpreadConstraints returns the pod's topology spread constraints with
+// whenUnsatisfiable: DoNotSchedule. ScheduleAnyway constraints only affect scoring.
+func hardSpreadConstraints(pod types.PodInfo) []corev1.TopologySpreadConstraint {
+	var constraints []corev1.TopologySpreadConstraint
+	for _, constraint := range pod.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable == corev1.DoNotSchedule {
+			constraints = append(constraints, constraint)
+		}
+	}
+	return constraints
+}
+
+// spreadCounts counts the pods matching a constraint in every topology domain. Only nodes
+// that carry all topology keys of the pod's hard constraints and qualify under the
+// constraint's nodeAffinityPolicy (default Honor) and nodeTaintsPolicy (default Ignore)
+// define domains. Terminating pods are not counted, as in the scheduler.
+func spreadCounts(pod types.PodInfo, constraint corev1.TopologySpreadConstraint,
+	constraints []corev1.TopologySpreadConstraint, allNodes []types.NodeInfo) map[string]int {
+	selector := spreadSelector(pod, constraint)
+	counts := make(map[string]int)
+
+	for _, node := range allNodes {
+		if !nodeCountsForSpread(pod, node, constraint, constraints) {
+			continue
+		}
+
+		value := node.Labels[constraint.TopologyKey]
+		if _, ok := counts[value]; !ok {
+			counts[value] = 0
+		}
+		for _, other := range node.Pods {
+			if !other.Terminating && other.Namespace == pod.Namespace && selector.Matches(labels.Set(other.Labels)) {
+				counts[value]++
+			}
+		}
+	}
+
+	return counts
+}
+
+// nodeCountsForSpread reports whether a node takes part in the domain calculation.
+func nodeCountsForSpread(pod types.PodInfo, node types.NodeInfo, constraint corev1.TopologySpreadConstraint,
+	constraints []corev1.TopologySpreadConstraint) bool {
+	for _, other := range constraints {
+		if _, ok := node.Labels[other.TopologyKey]; !ok {
+			return false
+		}
+	}
+
+	if constraint.NodeAffinityPolicy == nil || *constraint.NodeAffinityPolicy == corev1.NodeInclusionPolicyHonor {
+		if !labelsMatchSelector(node.Labels, pod.NodeSelector) {
+			return false
+		}
+		if pod.NodeAffinity != nil && pod.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil &&
+			!nodeSelectorMatches(pod.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution, node) {
+			return false
+		}
+	}
+
+	if constraint.NodeTaintsPolicy != nil && *constraint.NodeTaintsPolicy == corev1.NodeInclusionPolicyHonor &&
+		len(untoleratedTaints(node.Taints, pod.Tolerations)) > 0 {
+		return false
+	}
+
+	return true
+}
+
+// spreadSelector builds the label selector of a constraint, adding the pod's own values for
+// every key listed in matchLabelKeys.
+func spreadSelector(pod types.PodInfo, constraint corev1.TopologySpreadConstraint) labels.Selector {
+	if constraint.LabelSelector == nil {
+		return labels.Nothing()
+	}
+	selector, err := metav1.LabelSelectorAsSelector(constraint.LabelSelector)
+	if err != nil {
+		return labels.Nothing()
+	}
+
+	for _, key := range constraint.MatchLabelKeys {
+		value, ok := pod.Labels[key]
+		if !ok {
+			continue
+		}
+		requirement, err := labels.NewRequirement(key, selection.Equals, []string{value})
+		if err != nil {
+			continue
+		}
+		selector = selector.Add(*requirement)
+	}
+
+	return selector
+}
+
+// minSpreadCount returns the smallest number of matching pods in any domain, or zero when
+// fewer domains than minDomains exist.
+func minSpreadCount(counts map[string]int, minDomains *int32) int {
+	if len(counts) == 0 || (minDomains != nil && len(counts) < int(*minDomains)) {
+		return 0
+	}
+
+	values := make([]int, 0, len(counts))
+	for _, count := range counts {
+		values = append(values, count)
+	}
+	sort.Ints(values)
+	return values[0]
+}
+
+// selfMatch returns 1 when the pod is selected by its own constraint, as it then adds to
+// the count of the domain it lands in.
+func selfMatch(pod types.PodInfo, constraint corev1.TopologySpreadConstraint) int {
+	if spreadSelector(pod, constraint).Matches(labels.Set(pod.Labels)) {
+		return 1
+	}
+	return 0
+}
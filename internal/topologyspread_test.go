@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func zoneSpreadConstraint(maxSkew int32, matchLabels map[string]string) corev1.TopologySpreadConstraint {
+	return corev1.TopologySpreadConstraint{
+		MaxSkew:           maxSkew,
+		TopologyKey:       "topology.kubernetes.io/zone",
+		WhenUnsatisfiable: corev1.DoNotSchedule,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: matchLabels},
+	}
+}
+
+func TestFilterByTopologySpread(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	twoPodsInZoneA := []types.NodeInfo{
+		hostnameNode("node-a1", "a", labeledPod("default", "web-0", web)),
+		hostnameNode("node-a2", "a", labeledPod("default", "web-1", web)),
+		hostnameNode("node-b1", "b"),
+	}
+
+	newPod := func(constraints ...corev1.TopologySpreadConstraint) types.PodInfo {
+		pod := labeledPod("default", "web-2", web)
+		pod.TopologySpreadConstraints = constraints
+		return pod
+	}
+
+	t.Run("skewed zone excluded", func(t *testing.T) {
+		kept, exclusion := filterByTopologySpread(newPod(zoneSpreadConstraint(1, web)), twoPodsInZoneA, twoPodsInZoneA)
+
+		assert.Equal(t, []string{"node-b1"}, nodeInfoNames(kept))
+		assert.Equal(t, []string{"topology.kubernetes.io/zone=a has 2 matching pod(s), skew 3 > maxSkew 1 (min 0)"},
+			exclusion.details)
+	})
+
+	t.Run("only the skewed zone is a candidate", func(t *testing.T) {
+		kept, exclusion := filterByTopologySpread(newPod(zoneSpreadConstraint(1, web)), twoPodsInZoneA[:2], twoPodsInZoneA)
+
+		assert.Empty(t, kept)
+		assert.Equal(t, "all 2 node(s) violate topologySpreadConstraints: "+
+			"topology.kubernetes.io/zone=a has 2 matching pod(s), skew 3 > maxSkew 1 (min 0)", exclusion.reason)
+		assert.Equal(t, "Add capacity in the least populated domain(s) (topology.kubernetes.io/zone=b), "+
+			"raise maxSkew, or use whenUnsatisfiable: ScheduleAnyway", exclusion.suggestion)
+	})
+
+	t.Run("ScheduleAnyway is ignored", func(t *testing.T) {
+		constraint := zoneSpreadConstraint(1, web)
+		constraint.WhenUnsatisfiable = corev1.ScheduleAnyway
+
+		kept, _ := filterByTopologySpread(newPod(constraint), twoPodsInZoneA, twoPodsInZoneA)
+
+		assert.Len(t, kept, 3)
+	})
+
+	t.Run("minDomains not reached", func(t *testing.T) {
+		nodes := []types.NodeInfo{
+			hostnameNode("node-a1", "a", labeledPod("default", "web-0", web)),
+			hostnameNode("node-b1", "b", labeledPod("default", "web-1", web)),
+		}
+		minDomains := int32(3)
+		constraint := zoneSpreadConstraint(1, web)
+		constraint.MinDomains = &minDomains
+
+		kept, _ := filterByTopologySpread(newPod(constraint), nodes, nodes)
+
+		assert.Empty(t, kept)
+	})
+
+	t.Run("nodeAffinityPolicy", func(t *testing.T) {
+		nodeA := hostnameNode("node-a1", "a", labeledPod("default", "web-0", web), labeledPod("default", "web-1", web))
+		nodeA.Labels["pool"] = "web"
+		nodes := []types.NodeInfo{nodeA, hostnameNode("node-b1", "b")}
+
+		pod := newPod(zoneSpreadConstraint(1, web))
+		pod.NodeSelector = map[string]string{"pool": "web"}
+
+		kept, _ := filterByTopologySpread(pod, nodes[:1], nodes)
+		assert.Equal(t, []string{"node-a1"}, nodeInfoNames(kept))
+
+		ignore := corev1.NodeInclusionPolicyIgnore
+		pod.TopologySpreadConstraints[0].NodeAffinityPolicy = &ignore
+
+		kept, _ = filterByTopologySpread(pod, nodes[:1], nodes)
+		assert.Empty(t, kept)
+	})
+
+	t.Run("nodeTaintsPolicy", func(t *testing.T) {
+		nodeB := hostnameNode("node-b1", "b")
+		nodeB.Taints = []corev1.Taint{{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}}
+		nodes := []types.NodeInfo{twoPodsInZoneA[0], nodeB}
+
+		kept, _ := filterByTopologySpread(newPod(zoneSpreadConstraint(1, web)), nodes[:1], nodes)
+		assert.Empty(t, kept)
+
+		honor := corev1.NodeInclusionPolicyHonor
+		constraint := zoneSpreadConstraint(1, web)
+		constraint.NodeTaintsPolicy = &honor
+
+		kept, _ = filterByTopologySpread(newPod(constraint), nodes[:1], nodes)
+		assert.Equal(t, []string{"node-a1"}, nodeInfoNames(kept))
+	})
+
+	t.Run("matchLabelKeys limits counting to the same revision", func(t *testing.T) {
+		oldRevision := map[string]string{"app": "web", "pod-template-hash": "old"}
+		nodes := []types.NodeInfo{
+			hostnameNode("node-a1", "a", labeledPod("default", "web-old-0", oldRevision), labeledPod("default", "web-old-1", oldRevision)),
+			hostnameNode("node-b1", "b"),
+		}
+		constraint := zoneSpreadConstraint(1, web)
+		constraint.MatchLabelKeys = []string{"pod-template-hash"}
+		pod := newPod(constraint)
+		pod.Labels = map[string]string{"app": "web", "pod-template-hash": "new"}
+
+		kept, _ := filterByTopologySpread(pod, nodes, nodes)
+
+		assert.Equal(t, []string{"node-a1", "node-b1"}, nodeInfoNames(kept))
+	})
+
+	t.Run("terminating pods are not counted", func(t *testing.T) {
+		terminating := labeledPod("default", "web-1", web)
+		terminating.Terminating = true
+		nodes := []types.NodeInfo{
+			hostnameNode("node-a1", "a", labeledPod("default", "web-0", web), terminating),
+			hostnameNode("node-b1", "b"),
+		}
+
+		kept, _ := filterByTopologySpread(newPod(zoneSpreadConstraint(2, web)), nodes, nodes)
+
+		assert.Equal(t, []string{"node-a1", "node-b1"}, nodeInfoNames(kept))
+	})
+
+	t.Run("node without topology key", func(t *testing.T) {
+		nodes := []types.NodeInfo{{Name: "bare-node", AllocatableCPU: resource.MustParse("4")}}
+
+		kept, exclusion := filterByTopologySpread(newPod(zoneSpreadConstraint(1, web)), nodes, nodes)
+
+		assert.Empty(t, kept)
+		assert.Equal(t, "all 1 node(s) violate topologySpreadConstraints: node(s) without label topology.kubernetes.io/zone",
+			exclusion.reason)
+	})
+}
+
+func TestAnalyzeSinglePod_TopologySpread(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	nodes := []types.NodeInfo{
+		hostnameNode("node-a1", "a", labeledPod("default", "web-0", web)),
+		hostnameNode("node-b1", "b"),
+	}
+	nodes[1].AllocatableCPU = resource.MustParse("500m")
+
+	pod := labeledPod("default", "web-1", web)
+	pod.RequestsCPU = resource.MustParse("1")
+	pod.RequestsMemory = resource.MustParse("1Gi")
+	pod.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{zoneSpreadConstraint(1, web)}
+
+	result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+	assert.False(t, result.IsSchedulable)
+	assert.Equal(t, "requests.cpu = 1 exceeds all node allocatable.cpu (max: 500m); "+
+		"node(s) node-a1 have enough free resources but are excluded by topology spread constraints", result.Reason)
+	assert.Equal(t, []string{"topology.kubernetes.io/zone=a has 1 matching pod(s), skew 2 > maxSkew 1 (min 0)"},
+		result.SkewedDomains)
+}
@@ -0,0 +1,308 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// volumeClaimMissing is the phase recorded for claims that do not exist.
+const volumeClaimMissing = "Missing"
+
+// volumeConflict is a problem with one of the pod's claims, together with the nodes the
+// claim still allows. A nil allows function means the claim rules out every node.
+type volumeConflict struct {
+	description string
+	suggestion  string
+	predicate   string
+	allows      func(types.NodeInfo) bool
+}
+
+// filterByVolumes removes nodes that cannot use the pod's PersistentVolumeClaims. A missing
+// claim, or an unbound claim whose StorageClass binds immediately, rules out every node, as
+// the scheduler waits for the claim first. A bound claim restricts the pod to the nodes
+// matching its PersistentVolume's node affinity, and an unbound claim with
+// WaitForFirstConsumer binding restricts it to the StorageClass allowedTopologies.
+//
+// Parameters:
+//   - pod: The pod whose volume claims are checked
+//   - nodes: Candidate nodes to filter
+//
+// Returns:
+//   - []types.NodeInfo: Nodes compatible with every claim
+//   - nodeExclusion: The excluded nodes, with one detail per conflicting claim
+func filterByVolumes(pod types.PodInfo, nodes []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+	exclusion := nodeExclusion{constraint: "volume topology", code: types.ReasonCodeVolumeConflict}
+
+	conflicts := volumeConflicts(pod.VolumeClaims)
+	if len(conflicts) == 0 {
+		return nodes, exclusion
+	}
+
+	kept := make([]types.NodeInfo, 0, len(nodes))
+	active := make([]bool, len(conflicts))
+	for _, node := range nodes {
+		var predicates []string
+		for i, conflict := range conflicts {
+			if conflict.allows == nil || !conflict.allows(node) {
+				active[i] = true
+				if !containsString(predicates, conflict.predicate) {
+					predicates = append(predicates, conflict.predicate)
+				}
+			}
+		}
+
+		if len(predicates) == 0 {
+			kept = append(kept, node)
+		} else {
+			exclusion.exclude(node, predicates...)
+		}
+	}
+
+	var suggestions []string
+	for i, conflict := range conflicts {
+		if active[i] {
+			exclusion.details = append(exclusion.details, conflict.description)
+			suggestions = append(suggestions, conflict.suggestion)
+		}
+	}
+
+	exclusion.reason = fmt.Sprintf("all %d node(s) conflict with the pod's volumes: %s",
+		len(exclusion.nodes), strings.Join(exclusion.details, "; "))
+	exclusion.summary = fmt.Sprintf("%d node(s) conflict with the pod's volumes (%s)",
+		len(exclusion.nodes), strings.Join(exclusion.details, "; "))
+	exclusion.suggestion = strings.Join(suggestions, "; ")
+
+	return kept, exclusion
+}
+
+// volumeConflicts turns the pod's claims into the restrictions they impose on nodes. An
+// unbound claim whose StorageClass could not be resolved (not found, or not readable) has no
+// known binding mode and imposes none, rather than being treated as an immediate claim that
+// rules out every node.
+func volumeConflicts(claims []types.VolumeClaimInfo) []volumeConflict {
+	var conflicts []volumeConflict
+
+	for _, claim := range claims {
+		switch {
+		case claim.Phase == volumeClaimMissing:
+			conflicts = append(conflicts, volumeConflict{
+				description: fmt.Sprintf("persistentvolumeclaim %q not found", claim.Name),
+				suggestion:  fmt.Sprintf("Create persistentvolumeclaim %q", claim.Name),
+				predicate:   fmt.Sprintf("persistentvolumeclaim %q not found", claim.Name),
+			})
+		case claim.VolumeName == "" && claim.StorageClassName != "" && claim.VolumeBindingMode == "":
+			continue
+		case claim.VolumeName == "" && claim.VolumeBindingMode != string(storagev1.VolumeBindingWaitForFirstConsumer):
+			conflicts = append(conflicts, volumeConflict{
+				description: fmt.Sprintf("persistentvolumeclaim %q is not bound", claim.Name),
+				suggestion: fmt.Sprintf("Check why persistentvolumeclaim %q is not bound (provisioner or matching PersistentVolume)",
+					claim.Name),
+				predicate: "pod has unbound immediate PersistentVolumeClaims",
+			})
+		case claim.VolumeName != "" && claim.NodeAffinity != nil:
+			selector := claim.NodeAffinity
+			topology := formatNodeSelector(selector)
+			conflicts = append(conflicts, volumeConflict{
+				description: fmt.Sprintf("volume node affinity conflict: persistentvolumeclaim %q is bound to volume %q which requires %s",
+					claim.Name, claim.VolumeName, topology),
+				suggestion: fmt.Sprintf("Add schedulable capacity where %s, or move the data of persistentvolumeclaim %q to a reachable volume",
+					topology, claim.Name),
+				predicate: "node(s) had volume node affinity conflict",
+				allows:    func(node types.NodeInfo) bool { return nodeSelectorMatches(selector, node) },
+			})
+		case claim.VolumeName == "" && len(claim.AllowedTopologies) > 0:
+			terms := claim.AllowedTopologies
+			topology := formatTopologyTerms(terms)
+			conflicts = append(conflicts, volumeConflict{
+				description: fmt.Sprintf("persistentvolumeclaim %q can only be provisioned where %s (StorageClass %q allowedTopologies)",
+					claim.Name, topology, claim.StorageClassName),
+				suggestion: fmt.Sprintf("Add schedulable capacity where %s, or widen allowedTopologies of StorageClass %q",
+					topology, claim.StorageClassName),
+				predicate: "node(s) didn't find available persistent volumes to bind",
+				allows:    func(node types.NodeInfo) bool { return topologyTermsMatch(terms, node) },
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// topologyTermsMatch reports whether a node matches any of the StorageClass topology terms.
+// Within a term, every label requirement must match.
+func topologyTermsMatch(terms []corev1.TopologySelectorTerm, node types.NodeInfo) bool {
+	for _, term := range terms {
+		matches := true
+		for _, requirement := range term.MatchLabelExpressions {
+			value, ok := node.Labels[requirement.Key]
+			if !ok || !containsString(requirement.Values, value) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNodeSelector renders a node selector as "key in (a, b)" expressions joined by
+// "and" within a term and by "or" between terms.
+func formatNodeSelector(selector *corev1.NodeSelector) string {
+	terms := make([]string, 0, len(selector.NodeSelectorTerms))
+	for _, term := range selector.NodeSelectorTerms {
+		var requirements []string
+		for _, requirement := range append(append([]corev1.NodeSelectorRequirement{}, term.MatchExpressions...), term.MatchFields...) {
+			requirements = append(requirements, formatNodeSelectorRequirement(requirement))
+		}
+		terms = append(terms, strings.Join(requirements, " and "))
+	}
+	return strings.Join(terms, " or ")
+}
+
+func formatNodeSelectorRequirement(requirement corev1.NodeSelectorRequirement) string {
+	switch requirement.Operator {
+	case corev1.NodeSelectorOpExists:
+		return fmt.Sprintf("%s exists", requirement.Key)
+	case corev1.NodeSelectorOpDoesNotExist:
+		return fmt.Sprintf("%s does not exist", requirement.Key)
+	case corev1.NodeSelectorOpGt:
+		return fmt.Sprintf("%s > %s", requirement.Key, strings.Join(requirement.Values, ""))
+	case corev1.NodeSelectorOpLt:
+		return fmt.Sprintf("%s < %s", requirement.Key, strings.Join(requirement.Values, ""))
+	default:
+		return fmt.Sprintf("%s %s (%s)", requirement.Key, strings.ToLower(string(requirement.Operator)),
+			strings.Join(requirement.Values, ", "))
+	}
+}
+
+// formatTopologyTerms renders StorageClass topology terms in the same form as node selectors.
+func formatTopologyTerms(terms []corev1.TopologySelectorTerm) string {
+	formatted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		var requirements []string
+		for _, requirement := range term.MatchLabelExpressions {
+			requirements = append(requirements, fmt.Sprintf("%s in (%s)", requirement.Key, strings.Join(requirement.Values, ", ")))
+		}
+		formatted = append(formatted, strings.Join(requirements, " and "))
+	}
+	return strings.Join(formatted, " or ")
+}
+
+// filterByVolumeLimits removes nodes on which attaching the pod's CSI volumes would exceed a
+// driver's attach limit published in the node's CSINode object. Volumes are counted once per
+// node, so a volume already used by a pod running on the node does not count again.
+//
+// Parameters:
+//   - pod: The pod whose volume claims are counted
+//   - nodes: Candidate nodes to filter, with their bound pods attached
+//
+// Returns:
+//   - []types.NodeInfo: Nodes with enough free attachments for every driver
+//   - nodeExclusion: The excluded nodes, with their per-driver attachment counts as details
+func filterByVolumeLimits(pod types.PodInfo, nodes []types.NodeInfo) ([]types.NodeInfo, nodeExclusion) {
+	exclusion := nodeExclusion{constraint: "volume attach limits", code: types.ReasonCodeMaxVolumeCountExceeded}
+
+	kept := make([]types.NodeInfo, 0, len(nodes))
+	drivers := make(map[string]bool)
+	for _, node := range nodes {
+		if len(node.VolumeLimits) == 0 {
+			kept = append(kept, node)
+			continue
+		}
+
+		attached := attachedVolumes(node)
+		newVolumes := newVolumesByDriver(pod, attached)
+		var exceeded []string
+		for _, driver := range sortedDrivers(newVolumes) {
+			limit, ok := node.VolumeLimits[driver]
+			if !ok {
+				continue
+			}
+			count := len(attached[driver])
+			if int64(count+len(newVolumes[driver])) > limit {
+				drivers[driver] = true
+				exceeded = append(exceeded, fmt.Sprintf("%s %d/%d volumes attached", driver, count, limit))
+			}
+		}
+
+		if len(exceeded) == 0 {
+			kept = append(kept, node)
+			continue
+		}
+
+		exclusion.exclude(node, "node(s) exceed max volume count")
+		exclusion.details = append(exclusion.details, fmt.Sprintf("%s: %s", node.Name, strings.Join(exceeded, ", ")))
+	}
+
+	if len(exclusion.nodes) == 0 {
+		return kept, exclusion
+	}
+
+	driverNames := strings.Join(sortedKeys(drivers), ", ")
+	exclusion.reason = fmt.Sprintf("max volume count exceeded: all %d node(s) have reached the attach limit of %s",
+		len(exclusion.nodes), driverNames)
+	exclusion.summary = fmt.Sprintf("%d node(s) exceed max volume count (%s)", len(exclusion.nodes), driverNames)
+	exclusion.suggestion = fmt.Sprintf(
+		"Add nodes with free %s attachments, use instance types with a higher attach limit, or reduce the volumes per pod",
+		driverNames)
+
+	return kept, exclusion
+}
+
+// attachedVolumes returns the volumes used by the pods running on a node, keyed by CSI
+// driver. Each volume appears once even when several pods share it.
+func attachedVolumes(node types.NodeInfo) map[string]map[string]bool {
+	attached := make(map[string]map[string]bool)
+	for _, pod := range node.Pods {
+		for _, claim := range pod.VolumeClaims {
+			if claim.Driver == "" {
+				continue
+			}
+			if attached[claim.Driver] == nil {
+				attached[claim.Driver] = make(map[string]bool)
+			}
+			attached[claim.Driver][volumeKey(pod, claim)] = true
+		}
+	}
+	return attached
+}
+
+// newVolumesByDriver returns the pod's CSI volumes that are not yet attached to the node,
+// keyed by driver.
+func newVolumesByDriver(pod types.PodInfo, attached map[string]map[string]bool) map[string][]string {
+	volumes := make(map[string][]string)
+	for _, claim := range pod.VolumeClaims {
+		if claim.Driver == "" {
+			continue
+		}
+		key := volumeKey(pod, claim)
+		if attached[claim.Driver][key] || containsString(volumes[claim.Driver], key) {
+			continue
+		}
+		volumes[claim.Driver] = append(volumes[claim.Driver], key)
+	}
+	return volumes
+}
+
+// volumeKey identifies the volume behind a claim: the PersistentVolume name once bound,
+// otherwise the claim itself, which will get a volume of its own.
+func volumeKey(pod types.PodInfo, claim types.VolumeClaimInfo) string {
+	if claim.VolumeName != "" {
+		return claim.VolumeName
+	}
+	return podKey(pod) + "/" + claim.Name
+}
+
+// sortedDrivers returns the driver names of a per-driver volume list in sorted order.
+func sortedDrivers(volumes map[string][]string) []string {
+	drivers := make(map[string]bool, len(volumes))
+	for driver := range volumes {
+		drivers[driver] = true
+	}
+	return sortedKeys(drivers)
+}
@@ -0,0 +1,271 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syossan27/k8s-pending-resource-inspector/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func zoneNodeSelector(zones ...string) *corev1.NodeSelector {
+	return &corev1.NodeSelector{
+		NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key:      "topology.kubernetes.io/zone",
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   zones,
+			}},
+		}},
+	}
+}
+
+func TestFilterByVolumes(t *testing.T) {
+	nodes := []types.NodeInfo{
+		hostnameNode("node-a1", "a"),
+		hostnameNode("node-b1", "b"),
+	}
+
+	tests := []struct {
+		name               string
+		claims             []types.VolumeClaimInfo
+		expectedKept       []string
+		expectedDetails    []string
+		expectedSuggestion string
+	}{
+		{
+			name:         "no claims",
+			expectedKept: []string{"node-a1", "node-b1"},
+		},
+		{
+			name: "bound volume pinned to another zone",
+			claims: []types.VolumeClaimInfo{
+				{Name: "data", Phase: "Bound", VolumeName: "pv-1", NodeAffinity: zoneNodeSelector("a")},
+			},
+			expectedKept: []string{"node-a1"},
+			expectedDetails: []string{
+				`volume node affinity conflict: persistentvolumeclaim "data" is bound to volume "pv-1" which requires topology.kubernetes.io/zone in (a)`,
+			},
+			expectedSuggestion: `Add schedulable capacity where topology.kubernetes.io/zone in (a), or move the data of persistentvolumeclaim "data" to a reachable volume`,
+		},
+		{
+			name: "bound volume without node affinity",
+			claims: []types.VolumeClaimInfo{
+				{Name: "data", Phase: "Bound", VolumeName: "pv-1"},
+			},
+			expectedKept: []string{"node-a1", "node-b1"},
+		},
+		{
+			name: "missing claim",
+			claims: []types.VolumeClaimInfo{
+				{Name: "data", Phase: volumeClaimMissing},
+			},
+			expectedDetails:    []string{`persistentvolumeclaim "data" not found`},
+			expectedSuggestion: `Create persistentvolumeclaim "data"`,
+		},
+		{
+			name: "unbound claim with immediate binding",
+			claims: []types.VolumeClaimInfo{
+				{Name: "data", Phase: "Pending", StorageClassName: "standard", VolumeBindingMode: "Immediate"},
+			},
+			expectedDetails: []string{`persistentvolumeclaim "data" is not bound`},
+			expectedSuggestion: `Check why persistentvolumeclaim "data" is not bound ` +
+				`(provisioner or matching PersistentVolume)`,
+		},
+		{
+			name: "unbound claim whose StorageClass could not be resolved",
+			claims: []types.VolumeClaimInfo{
+				{Name: "data", Phase: "Pending", StorageClassName: "restricted"},
+			},
+			expectedKept: []string{"node-a1", "node-b1"},
+		},
+		{
+			name: "WaitForFirstConsumer claim limited by allowedTopologies",
+			claims: []types.VolumeClaimInfo{
+				{
+					Name:              "data",
+					Phase:             "Pending",
+					StorageClassName:  "zonal",
+					VolumeBindingMode: "WaitForFirstConsumer",
+					AllowedTopologies: []corev1.TopologySelectorTerm{{
+						MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{{
+							Key:    "topology.kubernetes.io/zone",
+							Values: []string{"b", "c"},
+						}},
+					}},
+				},
+			},
+			expectedKept: []string{"node-b1"},
+			expectedDetails: []string{
+				`persistentvolumeclaim "data" can only be provisioned where topology.kubernetes.io/zone in (b, c) (StorageClass "zonal" allowedTopologies)`,
+			},
+			expectedSuggestion: `Add schedulable capacity where topology.kubernetes.io/zone in (b, c), or widen allowedTopologies of StorageClass "zonal"`,
+		},
+		{
+			name: "WaitForFirstConsumer claim without allowedTopologies",
+			claims: []types.VolumeClaimInfo{
+				{Name: "data", Phase: "Pending", StorageClassName: "zonal", VolumeBindingMode: "WaitForFirstConsumer"},
+			},
+			expectedKept: []string{"node-a1", "node-b1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := types.PodInfo{Name: "db-0", Namespace: "default", VolumeClaims: tt.claims}
+
+			kept, exclusion := filterByVolumes(pod, nodes)
+
+			if tt.expectedKept == nil {
+				assert.Empty(t, kept)
+			} else {
+				assert.Equal(t, tt.expectedKept, nodeInfoNames(kept))
+			}
+			assert.Equal(t, tt.expectedDetails, exclusion.details)
+			assert.Equal(t, tt.expectedSuggestion, exclusion.suggestion)
+		})
+	}
+}
+
+func TestFormatNodeSelector(t *testing.T) {
+	selector := &corev1.NodeSelector{
+		NodeSelectorTerms: []corev1.NodeSelectorTerm{
+			{
+				MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "topology.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a", "b"}},
+					{Key: "local-ssd", Operator: corev1.NodeSelectorOpExists},
+				},
+			},
+			{
+				MatchFields: []corev1.NodeSelectorRequirement{
+					{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-1"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, "topology.kubernetes.io/zone in (a, b) and local-ssd exists or metadata.name in (node-1)",
+		formatNodeSelector(selector))
+}
+
+func TestAnalyzeSinglePod_VolumeNodeAffinityConflict(t *testing.T) {
+	nodes := []types.NodeInfo{hostnameNode("node-b1", "b")}
+
+	pod := types.PodInfo{
+		Name:           "db-0",
+		Namespace:      "default",
+		RequestsCPU:    resource.MustParse("1"),
+		RequestsMemory: resource.MustParse("1Gi"),
+		VolumeClaims: []types.VolumeClaimInfo{
+			{Name: "data", Phase: "Bound", VolumeName: "pv-1", NodeAffinity: zoneNodeSelector("a")},
+		},
+	}
+
+	result := (&Analyzer{}).analyzeSinglePod(pod, nodes, false)
+
+	assert.False(t, result.IsSchedulable)
+	assert.Equal(t, `all 1 node(s) conflict with the pod's volumes: volume node affinity conflict: `+
+		`persistentvolumeclaim "data" is bound to volume "pv-1" which requires topology.kubernetes.io/zone in (a)`,
+		result.Reason)
+	assert.Len(t, result.VolumeConflicts, 1)
+}
+
+func TestFilterByVolumeLimits(t *testing.T) {
+	const ebs = "ebs.csi.aws.com"
+
+	ebsClaim := func(name, volumeName string) types.VolumeClaimInfo {
+		return types.VolumeClaimInfo{Name: name, Phase: "Bound", VolumeName: volumeName, Driver: ebs}
+	}
+	runningPod := func(name string, claims ...types.VolumeClaimInfo) types.PodInfo {
+		pod := labeledPod("default", name, nil)
+		pod.VolumeClaims = claims
+		return pod
+	}
+	limitedNode := func(name string, limit int64, pods ...types.PodInfo) types.NodeInfo {
+		node := hostnameNode(name, "a", pods...)
+		node.VolumeLimits = map[string]int64{ebs: limit}
+		return node
+	}
+
+	tests := []struct {
+		name            string
+		nodes           []types.NodeInfo
+		claims          []types.VolumeClaimInfo
+		expectedKept    []string
+		expectedDetails []string
+	}{
+		{
+			name:         "free attachments left",
+			nodes:        []types.NodeInfo{limitedNode("node-1", 2, runningPod("db-0", ebsClaim("data-db-0", "pv-0")))},
+			claims:       []types.VolumeClaimInfo{ebsClaim("data-db-1", "pv-1")},
+			expectedKept: []string{"node-1"},
+		},
+		{
+			name:            "node at its attach limit",
+			nodes:           []types.NodeInfo{limitedNode("node-1", 1, runningPod("db-0", ebsClaim("data-db-0", "pv-0")))},
+			claims:          []types.VolumeClaimInfo{ebsClaim("data-db-1", "pv-1")},
+			expectedDetails: []string{"node-1: ebs.csi.aws.com 1/1 volumes attached"},
+		},
+		{
+			name:         "volume already attached is not counted again",
+			nodes:        []types.NodeInfo{limitedNode("node-1", 1, runningPod("db-0", ebsClaim("shared", "pv-0")))},
+			claims:       []types.VolumeClaimInfo{ebsClaim("shared", "pv-0")},
+			expectedKept: []string{"node-1"},
+		},
+		{
+			name:            "unbound claims each need an attachment",
+			nodes:           []types.NodeInfo{limitedNode("node-1", 1)},
+			claims:          []types.VolumeClaimInfo{{Name: "a", Driver: ebs}, {Name: "b", Driver: ebs}},
+			expectedDetails: []string{"node-1: ebs.csi.aws.com 0/1 volumes attached"},
+		},
+		{
+			name:         "other drivers are not limited",
+			nodes:        []types.NodeInfo{limitedNode("node-1", 0)},
+			claims:       []types.VolumeClaimInfo{{Name: "data", VolumeName: "pv-1", Driver: "pd.csi.storage.gke.io"}},
+			expectedKept: []string{"node-1"},
+		},
+		{
+			name:         "node without CSINode limits",
+			nodes:        []types.NodeInfo{hostnameNode("node-1", "a")},
+			claims:       []types.VolumeClaimInfo{ebsClaim("data", "pv-1")},
+			expectedKept: []string{"node-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := types.PodInfo{Name: "db-1", Namespace: "default", VolumeClaims: tt.claims}
+
+			kept, exclusion := filterByVolumeLimits(pod, tt.nodes)
+
+			if tt.expectedKept == nil {
+				assert.Empty(t, kept)
+			} else {
+				assert.Equal(t, tt.expectedKept, nodeInfoNames(kept))
+			}
+			assert.Equal(t, tt.expectedDetails, exclusion.details)
+		})
+	}
+}
+
+func TestAnalyzeSinglePod_MaxVolumeCountExceeded(t *testing.T) {
+	running := labeledPod("default", "db-0", nil)
+	running.VolumeClaims = []types.VolumeClaimInfo{{Name: "data-db-0", VolumeName: "pv-0", Driver: "ebs.csi.aws.com"}}
+	node := hostnameNode("node-1", "a", running)
+	node.VolumeLimits = map[string]int64{"ebs.csi.aws.com": 1}
+
+	pod := types.PodInfo{
+		Name:           "db-1",
+		Namespace:      "default",
+		RequestsCPU:    resource.MustParse("1"),
+		RequestsMemory: resource.MustParse("1Gi"),
+		VolumeClaims:   []types.VolumeClaimInfo{{Name: "data-db-1", VolumeName: "pv-1", Driver: "ebs.csi.aws.com"}},
+	}
+
+	result := (&Analyzer{}).analyzeSinglePod(pod, []types.NodeInfo{node}, false)
+
+	assert.False(t, result.IsSchedulable)
+	assert.Equal(t, "max volume count exceeded: all 1 node(s) have reached the attach limit of ebs.csi.aws.com", result.Reason)
+	assert.Equal(t, []string{"node-1: ebs.csi.aws.com 1/1 volumes attached"}, result.VolumeLimitNodes)
+}
@@ -5,6 +5,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type LogLevel string
@@ -23,39 +24,218 @@ const (
 	LogFormatText LogFormat = "text"
 )
 
+type PendingCategory string
+
+const (
+	PendingCategoryUnscheduled          PendingCategory = "Unscheduled"
+	PendingCategoryBoundNotRunning      PendingCategory = "BoundNotRunning"
+	PendingCategoryPreemptionInProgress PendingCategory = "PreemptionInProgress"
+)
+
+const ClusterAnalysisSchemaVersion = "v2"
+
+type ReasonCode string
+
+const (
+	ReasonCodeInsufficientCPU         ReasonCode = "InsufficientCPU"
+	ReasonCodeInsufficientMemory      ReasonCode = "InsufficientMemory"
+	ReasonCodeInsufficientResource    ReasonCode = "InsufficientResource"
+	ReasonCodeResourcesNotColocated   ReasonCode = "ResourcesNotColocated"
+	ReasonCodeNodeUnavailable         ReasonCode = "NodeUnavailable"
+	ReasonCodeNodeSelectorMismatch    ReasonCode = "NodeSelectorMismatch"
+	ReasonCodeNodeAffinityMismatch    ReasonCode = "NodeAffinityMismatch"
+	ReasonCodeTaintNotTolerated       ReasonCode = "TaintNotTolerated"
+	ReasonCodeVolumeConflict          ReasonCode = "VolumeConflict"
+	ReasonCodeMaxVolumeCountExceeded  ReasonCode = "MaxVolumeCountExceeded"
+	ReasonCodeHostPortConflict        ReasonCode = "HostPortConflict"
+	ReasonCodePodAffinityConflict     ReasonCode = "PodAffinityConflict"
+	ReasonCodeTopologySpreadViolation ReasonCode = "TopologySpreadViolation"
+	ReasonCodeTooManyPods             ReasonCode = "TooManyPods"
+	ReasonCodeFittingNodesExcluded    ReasonCode = "FittingNodesExcluded"
+	ReasonCodePreemptionPossible      ReasonCode = "PreemptionPossible"
+	ReasonCodeSchedulingGated         ReasonCode = "SchedulingGated"
+	ReasonCodeUnknownScheduler        ReasonCode = "UnknownScheduler"
+	ReasonCodeCollectivelyUnplaced    ReasonCode = "CollectivelyUnplaced"
+	ReasonCodePreemptionInProgress    ReasonCode = "PreemptionInProgress"
+	ReasonCodeContainersWaiting       ReasonCode = "ContainersWaiting"
+)
+
+type ReasonScope string
+
+const (
+	ReasonScopeAllocatable ReasonScope = "Allocatable"
+	ReasonScopeFree        ReasonScope = "Free"
+)
+
 type NodeInfo struct {
-	Name              string            `json:"name" yaml:"name"`
-	AllocatableCPU    resource.Quantity `json:"allocatableCpu" yaml:"allocatableCpu"`
-	AllocatableMemory resource.Quantity `json:"allocatableMemory" yaml:"allocatableMemory"`
-	Taints            []corev1.Taint    `json:"taints,omitempty" yaml:"taints,omitempty"`
-	Labels            map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Name              string                 `json:"name" yaml:"name"`
+	AllocatableCPU    resource.Quantity      `json:"allocatableCpu" yaml:"allocatableCpu"`
+	AllocatableMemory resource.Quantity      `json:"allocatableMemory" yaml:"allocatableMemory"`
+	Taints            []corev1.Taint         `json:"taints,omitempty" yaml:"taints,omitempty"`
+	Labels            map[string]string      `json:"labels,omitempty" yaml:"labels,omitempty"`
+	RequestedCPU      resource.Quantity      `json:"requestedCpu" yaml:"requestedCpu"`
+	RequestedMemory   resource.Quantity      `json:"requestedMemory" yaml:"requestedMemory"`
+	Allocatable       corev1.ResourceList    `json:"allocatable,omitempty" yaml:"allocatable,omitempty"`
+	Requested         corev1.ResourceList    `json:"requested,omitempty" yaml:"requested,omitempty"`
+	AllocatablePods   int64                  `json:"allocatablePods,omitempty" yaml:"allocatablePods,omitempty"`
+	PodCount          int64                  `json:"podCount" yaml:"podCount"`
+	Unschedulable     bool                   `json:"unschedulable,omitempty" yaml:"unschedulable,omitempty"`
+	Pods              []PodInfo              `json:"-" yaml:"-"`
+	Conditions        []corev1.NodeCondition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	VolumeLimits      map[string]int64       `json:"volumeLimits,omitempty" yaml:"volumeLimits,omitempty"`
 }
 
 type PodInfo struct {
-	Name           string               `json:"name" yaml:"name"`
-	Namespace      string               `json:"namespace" yaml:"namespace"`
-	RequestsCPU    resource.Quantity    `json:"requestsCpu" yaml:"requestsCpu"`
-	RequestsMemory resource.Quantity    `json:"requestsMemory" yaml:"requestsMemory"`
-	LimitsCPU      resource.Quantity    `json:"limitsCpu,omitempty" yaml:"limitsCpu,omitempty"`
-	LimitsMemory   resource.Quantity    `json:"limitsMemory,omitempty" yaml:"limitsMemory,omitempty"`
-	NodeAffinity   *corev1.NodeAffinity `json:"nodeAffinity,omitempty" yaml:"nodeAffinity,omitempty"`
-	Tolerations    []corev1.Toleration  `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+	Name                      string                            `json:"name" yaml:"name"`
+	Namespace                 string                            `json:"namespace" yaml:"namespace"`
+	NodeName                  string                            `json:"nodeName,omitempty" yaml:"nodeName,omitempty"`
+	RequestsCPU               resource.Quantity                 `json:"requestsCpu" yaml:"requestsCpu"`
+	RequestsMemory            resource.Quantity                 `json:"requestsMemory" yaml:"requestsMemory"`
+	RequestsCPUSource         string                            `json:"requestsCpuSource,omitempty" yaml:"requestsCpuSource,omitempty"`
+	RequestsMemorySource      string                            `json:"requestsMemorySource,omitempty" yaml:"requestsMemorySource,omitempty"`
+	LimitsCPU                 resource.Quantity                 `json:"limitsCpu,omitempty" yaml:"limitsCpu,omitempty"`
+	LimitsMemory              resource.Quantity                 `json:"limitsMemory,omitempty" yaml:"limitsMemory,omitempty"`
+	Requests                  corev1.ResourceList               `json:"requests,omitempty" yaml:"requests,omitempty"`
+	Limits                    corev1.ResourceList               `json:"limits,omitempty" yaml:"limits,omitempty"`
+	RuntimeClassName          string                            `json:"runtimeClassName,omitempty" yaml:"runtimeClassName,omitempty"`
+	Overhead                  corev1.ResourceList               `json:"overhead,omitempty" yaml:"overhead,omitempty"`
+	NodeSelector              map[string]string                 `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
+	NodeAffinity              *corev1.NodeAffinity              `json:"nodeAffinity,omitempty" yaml:"nodeAffinity,omitempty"`
+	Labels                    map[string]string                 `json:"labels,omitempty" yaml:"labels,omitempty"`
+	PodAffinity               *corev1.PodAffinity               `json:"podAffinity,omitempty" yaml:"podAffinity,omitempty"`
+	PodAntiAffinity           *corev1.PodAntiAffinity           `json:"podAntiAffinity,omitempty" yaml:"podAntiAffinity,omitempty"`
+	Tolerations               []corev1.Toleration               `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty" yaml:"topologySpreadConstraints,omitempty"`
+	VolumeClaims              []VolumeClaimInfo                 `json:"volumeClaims,omitempty" yaml:"volumeClaims,omitempty"`
+	HostPorts                 []corev1.ContainerPort            `json:"hostPorts,omitempty" yaml:"hostPorts,omitempty"`
+	PriorityClassName         string                            `json:"priorityClassName,omitempty" yaml:"priorityClassName,omitempty"`
+	Priority                  int32                             `json:"priority" yaml:"priority"`
+	PreemptionPolicy          string                            `json:"preemptionPolicy,omitempty" yaml:"preemptionPolicy,omitempty"`
+	SchedulerReports          []SchedulerReport                 `json:"-" yaml:"-"`
+	WaitingContainers         []ContainerWaiting                `json:"waitingContainers,omitempty" yaml:"waitingContainers,omitempty"`
+	SchedulerName             string                            `json:"schedulerName,omitempty" yaml:"schedulerName,omitempty"`
+	SchedulingGates           []string                          `json:"schedulingGates,omitempty" yaml:"schedulingGates,omitempty"`
+	NominatedNodeName         string                            `json:"nominatedNodeName,omitempty" yaml:"nominatedNodeName,omitempty"`
+	Terminating               bool                              `json:"terminating,omitempty" yaml:"terminating,omitempty"`
+	MemoryBackedEmptyDir      *resource.Quantity                `json:"memoryBackedEmptyDir,omitempty" yaml:"memoryBackedEmptyDir,omitempty"`
+}
+
+type VolumeClaimInfo struct {
+	Name              string                        `json:"name" yaml:"name"`
+	Phase             string                        `json:"phase" yaml:"phase"`
+	VolumeName        string                        `json:"volumeName,omitempty" yaml:"volumeName,omitempty"`
+	StorageClassName  string                        `json:"storageClassName,omitempty" yaml:"storageClassName,omitempty"`
+	VolumeBindingMode string                        `json:"volumeBindingMode,omitempty" yaml:"volumeBindingMode,omitempty"`
+	Driver            string                        `json:"driver,omitempty" yaml:"driver,omitempty"`
+	NodeAffinity      *corev1.NodeSelector          `json:"nodeAffinity,omitempty" yaml:"nodeAffinity,omitempty"`
+	AllowedTopologies []corev1.TopologySelectorTerm `json:"allowedTopologies,omitempty" yaml:"allowedTopologies,omitempty"`
+}
+
+type ContainerWaiting struct {
+	Container string `json:"container" yaml:"container"`
+	Reason    string `json:"reason" yaml:"reason"`
+	Message   string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+type SchedulerReport struct {
+	Source      string         `json:"source" yaml:"source"`
+	Reason      string         `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Message     string         `json:"message" yaml:"message"`
+	Time        time.Time      `json:"time" yaml:"time"`
+	TotalNodes  int            `json:"totalNodes,omitempty" yaml:"totalNodes,omitempty"`
+	NodeReasons map[string]int `json:"nodeReasons,omitempty" yaml:"nodeReasons,omitempty"`
+}
+
+type PodDisruptionBudgetInfo struct {
+	Name               string                `json:"name" yaml:"name"`
+	Namespace          string                `json:"namespace" yaml:"namespace"`
+	Selector           *metav1.LabelSelector `json:"selector,omitempty" yaml:"selector,omitempty"`
+	DisruptionsAllowed int32                 `json:"disruptionsAllowed" yaml:"disruptionsAllowed"`
+}
+
+type PreemptionCandidate struct {
+	NodeName             string   `json:"nodeName" yaml:"nodeName"`
+	Victims              []string `json:"victims" yaml:"victims"`
+	PDBViolations        int      `json:"pdbViolations" yaml:"pdbViolations"`
+	PDBViolationsUnknown bool     `json:"pdbViolationsUnknown,omitempty" yaml:"pdbViolationsUnknown,omitempty"`
+}
+
+type NodeFit struct {
+	Name                  string              `json:"name" yaml:"name"`
+	AllocatableCPU        resource.Quantity   `json:"allocatableCpu" yaml:"allocatableCpu"`
+	AllocatableMemory     resource.Quantity   `json:"allocatableMemory" yaml:"allocatableMemory"`
+	FreeCPU               resource.Quantity   `json:"freeCpu" yaml:"freeCpu"`
+	FreeMemory            resource.Quantity   `json:"freeMemory" yaml:"freeMemory"`
+	ExtendedFree          corev1.ResourceList `json:"extendedFree,omitempty" yaml:"extendedFree,omitempty"`
+	InsufficientResources []string            `json:"insufficientResources,omitempty" yaml:"insufficientResources,omitempty"`
+	Fits                  bool                `json:"fits" yaml:"fits"`
+}
+
+type NodeRejection struct {
+	Node    string   `json:"node" yaml:"node"`
+	Reasons []string `json:"reasons" yaml:"reasons"`
+}
+
+type ResourceAmount struct {
+	Name      corev1.ResourceName `json:"name" yaml:"name"`
+	Requested resource.Quantity   `json:"requested" yaml:"requested"`
+	Available resource.Quantity   `json:"available" yaml:"available"`
+}
+
+type ReasonDetail struct {
+	Code      ReasonCode       `json:"code" yaml:"code"`
+	Field     string           `json:"field,omitempty" yaml:"field,omitempty"`
+	Scope     ReasonScope      `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Resources []ResourceAmount `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Nodes     []string         `json:"nodes,omitempty" yaml:"nodes,omitempty"`
+	Details   []string         `json:"details,omitempty" yaml:"details,omitempty"`
+	Message   string           `json:"message" yaml:"message"`
 }
 
 type AnalysisResult struct {
-	Pod                PodInfo           `json:"pod" yaml:"pod"`
-	IsSchedulable      bool              `json:"isSchedulable" yaml:"isSchedulable"`
-	Reason             string            `json:"reason,omitempty" yaml:"reason,omitempty"`
-	Suggestion         string            `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
-	MaxAvailableCPU    resource.Quantity `json:"maxAvailableCpu" yaml:"maxAvailableCpu"`
-	MaxAvailableMemory resource.Quantity `json:"maxAvailableMemory" yaml:"maxAvailableMemory"`
+	Pod                   PodInfo              `json:"pod" yaml:"pod"`
+	Category              PendingCategory      `json:"category" yaml:"category"`
+	IsSchedulable         bool                 `json:"isSchedulable" yaml:"isSchedulable"`
+	Reason                string               `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Reasons               []ReasonDetail       `json:"reasons,omitempty" yaml:"reasons,omitempty"`
+	Suggestion            string               `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
+	MaxAvailableCPU       resource.Quantity    `json:"maxAvailableCpu" yaml:"maxAvailableCpu"`
+	MaxAvailableMemory    resource.Quantity    `json:"maxAvailableMemory" yaml:"maxAvailableMemory"`
+	MaxFreeCPU            resource.Quantity    `json:"maxFreeCpu" yaml:"maxFreeCpu"`
+	MaxFreeMemory         resource.Quantity    `json:"maxFreeMemory" yaml:"maxFreeMemory"`
+	FittingNodes          []string             `json:"fittingNodes,omitempty" yaml:"fittingNodes,omitempty"`
+	Nodes                 []NodeFit            `json:"nodes,omitempty" yaml:"nodes,omitempty"`
+	UnmatchedNodeSelector []string             `json:"unmatchedNodeSelector,omitempty" yaml:"unmatchedNodeSelector,omitempty"`
+	UntoleratedTaints     []string             `json:"untoleratedTaints,omitempty" yaml:"untoleratedTaints,omitempty"`
+	FullNodes             []string             `json:"fullNodes,omitempty" yaml:"fullNodes,omitempty"`
+	UnavailableNodes      []string             `json:"unavailableNodes,omitempty" yaml:"unavailableNodes,omitempty"`
+	PodAffinityConflicts  []string             `json:"podAffinityConflicts,omitempty" yaml:"podAffinityConflicts,omitempty"`
+	SkewedDomains         []string             `json:"skewedDomains,omitempty" yaml:"skewedDomains,omitempty"`
+	VolumeConflicts       []string             `json:"volumeConflicts,omitempty" yaml:"volumeConflicts,omitempty"`
+	VolumeLimitNodes      []string             `json:"volumeLimitNodes,omitempty" yaml:"volumeLimitNodes,omitempty"`
+	HostPortConflicts     []string             `json:"hostPortConflicts,omitempty" yaml:"hostPortConflicts,omitempty"`
+	Preemption            *PreemptionCandidate `json:"preemption,omitempty" yaml:"preemption,omitempty"`
+	SchedulerReports      []SchedulerReport    `json:"schedulerReports,omitempty" yaml:"schedulerReports,omitempty"`
+	SchedulerDisagreement string               `json:"schedulerDisagreement,omitempty" yaml:"schedulerDisagreement,omitempty"`
+	SchedulingGates       []string             `json:"schedulingGates,omitempty" yaml:"schedulingGates,omitempty"`
+	UnknownScheduler      string               `json:"unknownScheduler,omitempty" yaml:"unknownScheduler,omitempty"`
+	TerminatingVictims    []string             `json:"terminatingVictims,omitempty" yaml:"terminatingVictims,omitempty"`
+	CollectivelyUnplaced  bool                 `json:"collectivelyUnplaced,omitempty" yaml:"collectivelyUnplaced,omitempty"`
+	NodeRejections        []NodeRejection      `json:"nodeRejections,omitempty" yaml:"nodeRejections,omitempty"`
+	RejectionSummary      string               `json:"rejectionSummary,omitempty" yaml:"rejectionSummary,omitempty"`
+	Shortfall             corev1.ResourceList  `json:"shortfall,omitempty" yaml:"shortfall,omitempty"`
+	EmptyDirMemoryWarning string               `json:"emptyDirMemoryWarning,omitempty" yaml:"emptyDirMemoryWarning,omitempty"`
 }
 
 type ClusterAnalysis struct {
-	Timestamp         time.Time        `json:"timestamp" yaml:"timestamp"`
-	ClusterName       string           `json:"clusterName" yaml:"clusterName"`
-	TotalNodes        int              `json:"totalNodes" yaml:"totalNodes"`
-	TotalPendingPods  int              `json:"totalPendingPods" yaml:"totalPendingPods"`
-	UnschedulablePods []AnalysisResult `json:"unschedulablePods" yaml:"unschedulablePods"`
-	Summary           string           `json:"summary" yaml:"summary"`
+	SchemaVersion       string              `json:"schemaVersion" yaml:"schemaVersion"`
+	Timestamp           time.Time           `json:"timestamp" yaml:"timestamp"`
+	ClusterName         string              `json:"clusterName" yaml:"clusterName"`
+	TotalNodes          int                 `json:"totalNodes" yaml:"totalNodes"`
+	TotalPendingPods    int                 `json:"totalPendingPods" yaml:"totalPendingPods"`
+	UnschedulablePods   []AnalysisResult    `json:"unschedulablePods" yaml:"unschedulablePods"`
+	BoundNotRunningPods []AnalysisResult    `json:"boundNotRunningPods" yaml:"boundNotRunningPods"`
+	PreemptingPods      []AnalysisResult    `json:"preemptingPods" yaml:"preemptingPods"`
+	CollectiveShortfall corev1.ResourceList `json:"collectiveShortfall,omitempty" yaml:"collectiveShortfall,omitempty"`
+	Summary             string              `json:"summary" yaml:"summary"`
 }
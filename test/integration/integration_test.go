@@ -307,6 +307,76 @@ func (suite *IntegrationTestSuite) TestDifferentScenarios(t *testing.T) {
 		}
 	})
 
+	t.Run("node capacity consumed by running pods", func(t *testing.T) {
+		nodes := []*corev1.Node{
+			createNode("busy-node", "4", "8Gi", nil),
+		}
+		pods := []*corev1.Pod{
+			createRunningPod("running-pod", "default", "busy-node", "3500m", "6Gi"),
+			createPendingPod("pending-pod", "default", "1", "1Gi", "", ""),
+		}
+
+		clientset := fake.NewSimpleClientset()
+		for _, node := range nodes {
+			_, err := clientset.CoreV1().Nodes().Create(suite.ctx, node, metav1.CreateOptions{})
+			require.NoError(t, err)
+		}
+		for _, pod := range pods {
+			_, err := clientset.CoreV1().Pods(pod.Namespace).Create(suite.ctx, pod, metav1.CreateOptions{})
+			require.NoError(t, err)
+		}
+
+		fetcher := internal.NewFetcher(clientset)
+		analyzer := internal.NewAnalyzer(fetcher)
+		results, err := analyzer.AnalyzePodSchedulability(suite.ctx, "", false)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].IsSchedulable)
+		assert.Contains(t, results[0].Reason, "free cpu left by running pods")
+		require.Len(t, results[0].Nodes, 1)
+		assert.True(t, resource.MustParse("500m").Equal(results[0].Nodes[0].FreeCPU))
+	})
+
+	t.Run("node affinity matching no node", func(t *testing.T) {
+		nodes := []*corev1.Node{
+			createNode("linux-node", "4", "8Gi", nil),
+		}
+		pod := createPendingPod("windows-pod", "default", "100m", "128Mi", "", "")
+		pod.Spec.Affinity = &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{
+						{
+							MatchExpressions: []corev1.NodeSelectorRequirement{
+								{
+									Key:      "kubernetes.io/os",
+									Operator: corev1.NodeSelectorOpIn,
+									Values:   []string{"windows"},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		clientset := fake.NewSimpleClientset()
+		for _, node := range nodes {
+			_, err := clientset.CoreV1().Nodes().Create(suite.ctx, node, metav1.CreateOptions{})
+			require.NoError(t, err)
+		}
+		_, err := clientset.CoreV1().Pods(pod.Namespace).Create(suite.ctx, pod, metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		fetcher := internal.NewFetcher(clientset)
+		analyzer := internal.NewAnalyzer(fetcher)
+		results, err := analyzer.AnalyzePodSchedulability(suite.ctx, "", false)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].IsSchedulable)
+		assert.Contains(t, results[0].Reason, "no node matches affinity")
+	})
+
 	t.Run("node with taints", func(t *testing.T) {
 		taints := []corev1.Taint{
 			{
@@ -337,6 +407,9 @@ func (suite *IntegrationTestSuite) TestDifferentScenarios(t *testing.T) {
 		results, err := analyzer.AnalyzePodSchedulability(suite.ctx, "", false)
 		require.NoError(t, err)
 		assert.Len(t, results, 1)
+		assert.False(t, results[0].IsSchedulable)
+		assert.Contains(t, results[0].Reason, "node-role.kubernetes.io/master:NoSchedule")
+		assert.Equal(t, []string{"node-role.kubernetes.io/master:NoSchedule"}, results[0].UntoleratedTaints)
 	})
 }
 
@@ -594,3 +667,10 @@ func createPendingPod(name, namespace, requestCPU, requestMemory, limitCPU, limi
 
 	return pod
 }
+
+func createRunningPod(name, namespace, nodeName, requestCPU, requestMemory string) *corev1.Pod {
+	pod := createPendingPod(name, namespace, requestCPU, requestMemory, "", "")
+	pod.Spec.NodeName = nodeName
+	pod.Status.Phase = corev1.PodRunning
+	return pod
+}